@@ -0,0 +1,357 @@
+package pinboard
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TagEdge represents an edge in a TagGraph, Weight is the number of bookmarks
+// tagged with both From and To.
+type TagEdge struct {
+	From   string
+	To     string
+	Weight int
+}
+
+// TagGraph represents the co-occurrence graph of tags. Nodes are sorted by name
+// and their count is the number of bookmarks with the tag, edges are sorted by
+// From then To and From is always less than To.
+type TagGraph struct {
+	Nodes []Tag
+	Edges []TagEdge
+}
+
+// NewTagGraph builds the tag co-occurrence graph of bmarks.
+func NewTagGraph(bmarks []Bookmark) TagGraph {
+	counts := make(map[string]int)
+	weights := make(map[[2]string]int)
+	for _, b := range bmarks {
+		tags := uniqueTags(b.Tags)
+		sort.Strings(tags)
+		for i, t := range tags {
+			counts[t]++
+			for _, s := range tags[i+1:] {
+				weights[[2]string{t, s}]++
+			}
+		}
+	}
+	var g TagGraph
+	for t, c := range counts {
+		g.Nodes = append(g.Nodes, Tag{Name: t, Count: c})
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].Name < g.Nodes[j].Name
+	})
+	for k, w := range weights {
+		g.Edges = append(g.Edges, TagEdge{From: k[0], To: k[1], Weight: w})
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+// uniqueTags returns the non empty tags in tags with duplicates removed.
+func uniqueTags(tags []string) []string {
+	var u []string
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		if t != "" && !seen[t] {
+			seen[t] = true
+			u = append(u, t)
+		}
+	}
+	return u
+}
+
+// Neighbours returns the edges of tag, heaviest first.
+func (g TagGraph) Neighbours(tag string) []TagEdge {
+	var e []TagEdge
+	for _, edge := range g.Edges {
+		switch tag {
+		case edge.From:
+			e = append(e, edge)
+		case edge.To:
+			e = append(e, TagEdge{From: tag, To: edge.From, Weight: edge.Weight})
+		}
+	}
+	sort.SliceStable(e, func(i, j int) bool { return e[i].Weight > e[j].Weight })
+	return e
+}
+
+// dotQuote quotes s as a DOT identifier.
+func dotQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language.
+func (g TagGraph) WriteDOT(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "graph tags {")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(b, "  %s [count=%d];\n", dotQuote(n.Name), n.Count)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "  %s -- %s [weight=%d];\n", dotQuote(e.From),
+			dotQuote(e.To), e.Weight)
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// graphML and friends describe the subset of GraphML written by WriteGraphML.
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph as a GraphML document, tag counts and edge
+// weights are written as the "count" and "weight" attributes.
+func (g TagGraph) WriteGraphML(w io.Writer) error {
+	doc := graphML{XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "count", For: "node", Name: "count", Type: "int"},
+			{ID: "weight", For: "edge", Name: "weight", Type: "int"},
+		},
+		Graph: graphMLGraph{ID: "tags", EdgeDefault: "undirected"},
+	}
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: n.Name,
+			Data: []graphMLData{{Key: "count", Value: strconv.Itoa(n.Count)}},
+		})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: e.From,
+			Target: e.To,
+			Data:   []graphMLData{{Key: "weight", Value: strconv.Itoa(e.Weight)}},
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Singletons returns the tags used on a single bookmark, these are often typos
+// or tags which were never used again.
+func Singletons(tags []Tag) []Tag {
+	var s []Tag
+	for _, t := range tags {
+		if t.Count == 1 {
+			s = append(s, t)
+		}
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].Name < s[j].Name })
+	return s
+}
+
+// invariant are words ending in "s" which are not plurals.
+var invariant = map[string]bool{
+	"news": true, "series": true, "species": true, "physics": true,
+	"mathematics": true, "economics": true, "politics": true, "ethics": true,
+	"graphics": true, "analytics": true, "statistics": true, "linguistics": true,
+}
+
+// singular returns a naive singular form of the lower case word w. words are
+// the other words w is compared with, if w less its "s" is one of them that is
+// taken to be the singular, so e.g. "caches" pairs with "cache" but "boxes"
+// still pairs with "box". Words ending in "ss", "us" and "is" are left alone.
+func singular(w string, words map[string]bool) string {
+	if len(w) <= 3 || !strings.HasSuffix(w, "s") || invariant[w] ||
+		strings.HasSuffix(w, "ss") || strings.HasSuffix(w, "us") ||
+		strings.HasSuffix(w, "is") {
+		return w
+	}
+	if words[w[:len(w)-1]] {
+		return w[:len(w)-1]
+	}
+	if len(w) > 4 && strings.HasSuffix(w, "ies") {
+		return w[:len(w)-3] + "y"
+	}
+	if strings.HasSuffix(w, "es") {
+		stem := w[:len(w)-2]
+		if words[stem] || strings.HasSuffix(stem, "ss") || strings.HasSuffix(stem, "x") ||
+			strings.HasSuffix(stem, "z") || strings.HasSuffix(stem, "ch") ||
+			strings.HasSuffix(stem, "sh") {
+			return stem
+		}
+	}
+	return w[:len(w)-1]
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	r, s := []rune(a), []rune(b)
+	prev := make([]int, len(s)+1)
+	cur := make([]int, len(s)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(r); i++ {
+		cur[0] = i
+		for j := 1; j <= len(s); j++ {
+			cost := 1
+			if r[i-1] == s[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(s)]
+}
+
+// min3 returns the smallest of a, b and c.
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// minEditLength is the shortest tag compared by edit distance, short tags such
+// as "go" and "js" are too close to everything else.
+const minEditLength = 5
+
+// SimilarTags groups tags which are probably the same tag: they differ only by
+// case, by a plural or by at most maxDist edits. Tags shorter than five
+// characters are not compared by edit distance and maxDist <= 0 disables it.
+// Each group is sorted by descending count, so the first tag is the obvious
+// one to keep, and groups are sorted by their first tag.
+func SimilarTags(tags []Tag, maxDist int) [][]Tag {
+	parent := make([]int, len(tags))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	words := make(map[string]bool)
+	for _, t := range tags {
+		words[strings.ToLower(t.Name)] = true
+	}
+	keys := make([]string, len(tags))
+	for i, t := range tags {
+		keys[i] = singular(strings.ToLower(t.Name), words)
+	}
+	for i := range tags {
+		for j := i + 1; j < len(tags); j++ {
+			similar := keys[i] == keys[j]
+			if !similar && maxDist > 0 && len(keys[i]) >= minEditLength &&
+				len(keys[j]) >= minEditLength {
+				similar = editDistance(keys[i], keys[j]) <= maxDist
+			}
+			if similar {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+	groups := make(map[int][]Tag)
+	for i, t := range tags {
+		r := find(i)
+		groups[r] = append(groups[r], t)
+	}
+	var similar [][]Tag
+	for _, g := range groups {
+		if len(g) < 2 {
+			continue
+		}
+		sort.Slice(g, func(i, j int) bool {
+			if g[i].Count != g[j].Count {
+				return g[i].Count > g[j].Count
+			}
+			return g[i].Name < g[j].Name
+		})
+		similar = append(similar, g)
+	}
+	sort.Slice(similar, func(i, j int) bool {
+		return similar[i][0].Name < similar[j][0].Name
+	})
+	return similar
+}
+
+// TagUsage returns, for each tag, the number of bookmarks tagged with it on
+// each day, sorted by date. Days without bookmarks are omitted.
+func TagUsage(bmarks []Bookmark) map[string][]Post {
+	days := make(map[string]map[time.Time]int)
+	for _, b := range bmarks {
+		if b.Created.IsZero() {
+			continue
+		}
+		c := b.Created.UTC()
+		d := time.Date(c.Year(), c.Month(), c.Day(), 0, 0, 0, 0, time.UTC)
+		for _, t := range uniqueTags(b.Tags) {
+			if days[t] == nil {
+				days[t] = make(map[time.Time]int)
+			}
+			days[t][d]++
+		}
+	}
+	usage := make(map[string][]Post)
+	for t, m := range days {
+		var posts []Post
+		for d, c := range m {
+			posts = append(posts, Post{Date: d, Count: c})
+		}
+		sort.Slice(posts, func(i, j int) bool {
+			return posts[i].Date.Before(posts[j].Date)
+		})
+		usage[t] = posts
+	}
+	return usage
+}
@@ -0,0 +1,65 @@
+package pinboard
+
+import "testing"
+
+// SINGULAR
+
+func TestSingular(t *testing.T) {
+	tests := map[string]string{
+		"recipes":   "recipe",
+		"libraries": "library",
+		"boxes":     "box",
+		"watches":   "watch",
+		"classes":   "class",
+		"cases":     "case",
+		"databases": "database",
+		"courses":   "course",
+		"releases":  "release",
+		"css":       "css",
+		"class":     "class",
+		"go":        "go",
+		"news":      "news",
+		"status":    "status",
+		"analysis":  "analysis",
+	}
+	for in, want := range tests {
+		if got := singular(in, nil); got != want {
+			t.Errorf("singular %s: got %s want %s", in, got, want)
+		}
+	}
+}
+
+func TestSingularWithWords(t *testing.T) {
+	words := map[string]bool{"cache": true, "box": true, "new": true}
+	tests := map[string]string{
+		"caches": "cache",
+		"boxes":  "box",
+		"news":   "news",
+	}
+	for in, want := range tests {
+		if got := singular(in, words); got != want {
+			t.Errorf("singular %s: got %s want %s", in, got, want)
+		}
+	}
+}
+
+// EDITDISTANCE
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"javascript", "javscript", 1},
+		{"café", "cafe", 1},
+	}
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("edit distance %s %s: got %d want %d", test.a, test.b,
+				got, test.want)
+		}
+	}
+}
@@ -0,0 +1,530 @@
+package pinboard
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ArchiveFormat is the file format snapshots are saved in.
+type ArchiveFormat int
+
+// Snapshot formats.
+const (
+	// SingleFile saves the page as a single HTML file, with images, scripts
+	// and stylesheets inlined as data URIs so it renders offline.
+	SingleFile ArchiveFormat = iota
+	// WARC saves the page and its resources, unmodified, as WARC 1.1 response
+	// records, the format used by web archives.
+	WARC
+)
+
+// ArchiveStatus records the outcome of archiving a bookmark.
+type ArchiveStatus struct {
+	URL       string
+	Key       string    // Directory key, the bookmarks Hash.
+	File      string    // Snapshot file relative to the archive directory.
+	Status    int       // HTTP status of the page, 0 if it could not be fetched.
+	Error     string    // Why the page could not be archived.
+	Fetched   time.Time // Time of the snapshot.
+	Resources int       // Resources saved with the page.
+	Failed    int       // Resources which could not be fetched.
+	Digest    string    // SHA-1 of the snapshot file.
+}
+
+// OK reports whether the page was archived.
+func (s ArchiveStatus) OK() bool {
+	return s.Status == http.StatusOK && s.Error == ""
+}
+
+// Archiver saves local snapshots of bookmarked pages, so links survive link
+// rot. Snapshots are stored under Dir by the Hash of the bookmark, which is
+// the MD5 of its URL, in Dir/ab/abcdef.../ along with a status.json file
+// recording how fetching went.
+type Archiver struct {
+	Dir    string
+	Format ArchiveFormat
+	// Client used to fetch pages. If nil a client with Timeout is used.
+	Client *http.Client
+	// Timeout for fetching each page and resource. Default is 30 seconds.
+	Timeout time.Duration
+	// MaxSize is the maximum size in bytes of the page and of each resource.
+	// Default is 10MB, larger resources are counted as failed.
+	MaxSize int64
+	// MaxResources is the maximum number of resources saved with a page.
+	// Default is 200.
+	MaxResources int
+}
+
+const (
+	defaultArchiveTimeout   = 30 * time.Second
+	defaultArchiveMaxSize   = 10 << 20
+	defaultArchiveResources = 200
+)
+
+// archiveKey returns the key a bookmark is stored under, its Hash or if that
+// is missing the MD5 of its URL, which is how Pinboard computes Hash.
+func archiveKey(b Bookmark) string {
+	h := strings.ToLower(string(b.Hash))
+	if len(h) == 32 {
+		if _, err := hex.DecodeString(h); err == nil {
+			return h
+		}
+	}
+	sum := md5.Sum([]byte(b.URL))
+	return hex.EncodeToString(sum[:])
+}
+
+// dir returns the directory of a key relative to the archive directory.
+func (a Archiver) dir(key string) string {
+	return filepath.Join(key[:2], key)
+}
+
+// Status returns the status of the last snapshot of a bookmark, false if it
+// was never archived.
+func (a Archiver) Status(b Bookmark) (ArchiveStatus, bool, error) {
+	var s ArchiveStatus
+	err := loadJSON(filepath.Join(a.Dir, a.dir(archiveKey(b)), "status.json"), &s)
+	if err != nil {
+		return ArchiveStatus{}, false, err
+	}
+	return s, s.Key != "", nil
+}
+
+// Path returns the path of the snapshot file of a bookmark, it may not exist.
+func (a Archiver) Path(b Bookmark) string {
+	name := "index.html"
+	if a.Format == WARC {
+		name = "archive.warc"
+	}
+	return filepath.Join(a.Dir, a.dir(archiveKey(b)), name)
+}
+
+// ArchiveAll archives bookmarks, e.g. from Bookmarks or Recent, skipping those
+// already archived. Errors fetching a page are recorded in its status, only
+// errors writing the archive stop it.
+func (a Archiver) ArchiveAll(bmarks []Bookmark) ([]ArchiveStatus, error) {
+	var all []ArchiveStatus
+	for _, b := range bmarks {
+		s, ok, err := a.Status(b)
+		if err != nil {
+			return all, err
+		}
+		if !ok || !s.OK() {
+			if s, err = a.Archive(b); err != nil {
+				return all, err
+			}
+		}
+		all = append(all, s)
+	}
+	return all, nil
+}
+
+// Archive fetches the page of a bookmark and its resources and saves a
+// snapshot, replacing any earlier one. Errors fetching the page are recorded
+// in the returned status, an error is only returned if the archive can't be
+// written.
+func (a Archiver) Archive(b Bookmark) (ArchiveStatus, error) {
+	key := archiveKey(b)
+	s := ArchiveStatus{URL: b.URL, Key: key, Fetched: time.Now().UTC()}
+	dir := filepath.Join(a.Dir, a.dir(key))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return s, err
+	}
+
+	page := a.fetch(b.URL)
+	s.Status = page.status
+	switch {
+	case page.err != nil:
+		s.Error = page.err.Error()
+	case page.status != http.StatusOK:
+		s.Error = "HTTP " + strconv.Itoa(page.status) + " " + http.StatusText(page.status)
+	case !isHTML(page.header):
+		s.Error = "not an HTML page: " + page.header.Get("Content-Type")
+	}
+	if s.Error != "" {
+		return s, saveJSON(filepath.Join(dir, "status.json"), s)
+	}
+
+	doc := string(page.body)
+	tokens := tokenizeHTML(doc)
+	base := baseURL(page.url, tokens)
+	resources := a.fetchResources(base, tokens)
+	var snapshot []byte
+	if a.Format == WARC {
+		var buf bytes.Buffer
+		writeWARC(&buf, s.Fetched, append([]*archivedResponse{page}, resources.list...))
+		snapshot = buf.Bytes()
+	} else {
+		snapshot = []byte(singleFile(doc, tokens, base, resources))
+	}
+	for _, r := range resources.list {
+		if r.err != nil || r.status != http.StatusOK {
+			s.Failed++
+		} else {
+			s.Resources++
+		}
+	}
+
+	path := a.Path(b)
+	if err := writeFileAtomic(path, snapshot); err != nil {
+		return s, err
+	}
+	sum := sha1.Sum(snapshot)
+	s.File = filepath.Join(a.dir(key), filepath.Base(path))
+	s.Digest = hex.EncodeToString(sum[:])
+	return s, saveJSON(filepath.Join(dir, "status.json"), s)
+}
+
+// archivedResponse is a fetched page or resource.
+type archivedResponse struct {
+	url    string
+	status int
+	proto  string
+	header http.Header
+	body   []byte
+	err    error
+}
+
+// client returns the HTTP client used to fetch pages and resources.
+func (a Archiver) client() *http.Client {
+	if a.Client != nil {
+		return a.Client
+	}
+	t := a.Timeout
+	if t == 0 {
+		t = defaultArchiveTimeout
+	}
+	return &http.Client{Timeout: t}
+}
+
+// fetch fetches URL, following redirects.
+func (a Archiver) fetch(URL string) *archivedResponse {
+	r := &archivedResponse{url: URL}
+	rsp, err := a.client().Get(URL)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	defer rsp.Body.Close()
+	r.url = rsp.Request.URL.String()
+	r.status = rsp.StatusCode
+	r.proto = rsp.Proto
+	r.header = rsp.Header
+	max := a.MaxSize
+	if max == 0 {
+		max = defaultArchiveMaxSize
+	}
+	r.body, r.err = ioutil.ReadAll(io.LimitReader(rsp.Body, max+1))
+	if r.err == nil && int64(len(r.body)) > max {
+		r.body, r.err = nil, errors.New("larger than "+strconv.FormatInt(max, 10)+" bytes")
+	}
+	return r
+}
+
+// isHTML reports whether a response is an HTML page.
+func isHTML(h http.Header) bool {
+	ct, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return ct == "text/html" || ct == "application/xhtml+xml"
+}
+
+// baseURL returns the URL relative links in a page resolve against, the
+// pages URL or its <base href>.
+func baseURL(pageURL string, tokens []htmlToken) *url.URL {
+	base, _ := url.Parse(pageURL)
+	for _, t := range tokens {
+		if t.typ == htmlStartTag && t.name == "base" && t.attrs["href"] != "" {
+			if u, err := base.Parse(t.attrs["href"]); err == nil {
+				return u
+			}
+			break
+		}
+	}
+	return base
+}
+
+// resourceAttr returns the attribute of a start tag which refers to a
+// resource saved with the page, or "" if it refers to none.
+func resourceAttr(t htmlToken) string {
+	if t.typ != htmlStartTag {
+		return ""
+	}
+	switch t.name {
+	case "img", "script", "source", "audio", "video", "track", "embed":
+		if t.attrs["src"] != "" {
+			return "src"
+		}
+		if t.name == "video" && t.attrs["poster"] != "" {
+			return "poster"
+		}
+	case "link":
+		for _, rel := range strings.Fields(strings.ToLower(t.attrs["rel"])) {
+			if (rel == "stylesheet" || rel == "icon" || rel == "apple-touch-icon") &&
+				t.attrs["href"] != "" {
+				return "href"
+			}
+		}
+	}
+	return ""
+}
+
+// cssURL matches url() references in CSS.
+var cssURL = regexp.MustCompile(`url\(\s*(?:"([^"]*)"|'([^']*)'|([^)'"\s]*))\s*\)`)
+
+// cssRefs returns the URLs referred to by a stylesheet resolved against base.
+func cssRefs(css string, base *url.URL) []string {
+	var refs []string
+	for _, m := range cssURL.FindAllStringSubmatch(css, -1) {
+		ref := m[1] + m[2] + m[3]
+		if ref == "" || strings.HasPrefix(ref, "data:") {
+			continue
+		}
+		if u, err := base.Parse(ref); err == nil {
+			refs = append(refs, u.String())
+		}
+	}
+	return refs
+}
+
+// resourceSet is the resources of a page, by absolute URL and in the order
+// they were fetched.
+type resourceSet struct {
+	byURL map[string]*archivedResponse
+	list  []*archivedResponse
+}
+
+// fetchResources fetches the resources a page refers to, and the resources
+// its stylesheets refer to.
+func (a Archiver) fetchResources(base *url.URL, tokens []htmlToken) resourceSet {
+	max := a.MaxResources
+	if max == 0 {
+		max = defaultArchiveResources
+	}
+	set := resourceSet{byURL: make(map[string]*archivedResponse)}
+	var queue []string
+	add := func(ref string, base *url.URL) {
+		u, err := base.Parse(strings.TrimSpace(ref))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		u.Fragment = ""
+		queue = append(queue, u.String())
+	}
+	inStyle := false
+	for _, t := range tokens {
+		if attr := resourceAttr(t); attr != "" {
+			add(t.attrs[attr], base)
+		}
+		switch {
+		case t.typ == htmlStartTag && t.name == "style":
+			inStyle = true
+		case t.typ == htmlEndTag && t.name == "style":
+			inStyle = false
+		case t.typ == htmlText && inStyle:
+			for _, ref := range cssRefs(t.text, base) {
+				add(ref, base)
+			}
+		}
+	}
+	for len(queue) > 0 && len(set.list) < max {
+		u := queue[0]
+		queue = queue[1:]
+		if _, ok := set.byURL[u]; ok {
+			continue
+		}
+		r := a.fetch(u)
+		set.byURL[u] = r
+		set.list = append(set.list, r)
+		ct, _, _ := mime.ParseMediaType(r.header.Get("Content-Type"))
+		if r.err == nil && ct == "text/css" {
+			cssBase, _ := url.Parse(r.url)
+			for _, ref := range cssRefs(string(r.body), cssBase) {
+				add(ref, cssBase)
+			}
+		}
+	}
+	return set
+}
+
+// dataURI returns a resource as a data URI, or "" if it was not fetched.
+func (set resourceSet) dataURI(ref string, base *url.URL) string {
+	u, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ""
+	}
+	u.Fragment = ""
+	r, ok := set.byURL[u.String()]
+	if !ok || r.err != nil || r.status != http.StatusOK {
+		return ""
+	}
+	body := r.body
+	ct := r.header.Get("Content-Type")
+	if t, _, _ := mime.ParseMediaType(ct); t == "text/css" {
+		cssBase, _ := url.Parse(r.url)
+		body = []byte(set.inlineCSS(string(body), cssBase))
+	}
+	if ct == "" {
+		ct = http.DetectContentType(body)
+	}
+	return "data:" + ct + ";base64," + base64.StdEncoding.EncodeToString(body)
+}
+
+// inlineCSS replaces the url() references of a stylesheet with data URIs.
+// Stylesheets nested more than one level deep keep their references.
+func (set resourceSet) inlineCSS(css string, base *url.URL) string {
+	return cssURL.ReplaceAllStringFunc(css, func(m string) string {
+		g := cssURL.FindStringSubmatch(m)
+		ref := g[1] + g[2] + g[3]
+		u, err := base.Parse(strings.TrimSpace(ref))
+		if err != nil {
+			return m
+		}
+		u.Fragment = ""
+		r, ok := set.byURL[u.String()]
+		if !ok || r.err != nil || r.status != http.StatusOK {
+			return m
+		}
+		ct := r.header.Get("Content-Type")
+		if ct == "" {
+			ct = http.DetectContentType(r.body)
+		}
+		return `url("data:` + ct + ";base64," + base64.StdEncoding.EncodeToString(r.body) + `")`
+	})
+}
+
+// renderTag renders a start tag, attributes sorted by name.
+func renderTag(t htmlToken) string {
+	names := make([]string, 0, len(t.attrs))
+	for n := range t.attrs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	s := "<" + t.name
+	for _, n := range names {
+		s += " " + n + `="` + html.EscapeString(t.attrs[n]) + `"`
+	}
+	return s + ">"
+}
+
+// singleFile returns the page with its resources inlined as data URIs.
+// Resources which could not be fetched keep their original URL, srcset is
+// dropped so browsers use the inlined src.
+func singleFile(doc string, tokens []htmlToken, base *url.URL, set resourceSet) string {
+	var b strings.Builder
+	prev := 0
+	inStyle := false
+	for _, t := range tokens {
+		switch {
+		case t.typ == htmlStartTag && t.name == "style":
+			inStyle = true
+		case t.typ == htmlEndTag && t.name == "style":
+			inStyle = false
+		}
+		var out string
+		switch attr := resourceAttr(t); {
+		case attr != "":
+			uri := set.dataURI(t.attrs[attr], base)
+			_, srcset := t.attrs["srcset"]
+			if uri == "" && !srcset {
+				continue
+			}
+			t.attrs = copyAttrs(t.attrs)
+			if uri != "" {
+				t.attrs[attr] = uri
+			}
+			delete(t.attrs, "srcset")
+			out = renderTag(t)
+		case t.typ == htmlStartTag && t.attrs["srcset"] != "":
+			// <source srcset> in a <picture>.
+			t.attrs = copyAttrs(t.attrs)
+			delete(t.attrs, "srcset")
+			out = renderTag(t)
+		case t.typ == htmlText && inStyle:
+			out = set.inlineCSS(doc[t.start:t.end], base)
+		default:
+			continue
+		}
+		b.WriteString(doc[prev:t.start])
+		b.WriteString(out)
+		prev = t.end
+	}
+	b.WriteString(doc[prev:])
+	return b.String()
+}
+
+// copyAttrs returns a copy of the attributes of a tag.
+func copyAttrs(attrs map[string]string) map[string]string {
+	c := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		c[k] = v
+	}
+	return c
+}
+
+// newRecordID returns a WARC record ID.
+func newRecordID() string {
+	var u [16]byte
+	rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40 // version 4.
+	u[8] = u[8]&0x3f | 0x80 // variant 10.
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+// writeWARCRecord writes a WARC record with the given headers and block.
+func writeWARCRecord(w io.Writer, typ string, date time.Time, headers [][2]string, block []byte) {
+	fmt.Fprintf(w, "WARC/1.1\r\nWARC-Type: %s\r\nWARC-Record-ID: %s\r\nWARC-Date: %s\r\n",
+		typ, newRecordID(), date.UTC().Format(time.RFC3339))
+	for _, h := range headers {
+		fmt.Fprintf(w, "%s: %s\r\n", h[0], h[1])
+	}
+	fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(block))
+	w.Write(block)
+	io.WriteString(w, "\r\n\r\n")
+}
+
+// writeWARC writes a warcinfo record followed by a response record for each
+// fetched response.
+func writeWARC(w io.Writer, date time.Time, responses []*archivedResponse) {
+	writeWARCRecord(w, "warcinfo", date, [][2]string{
+		{"Content-Type", "application/warc-fields"},
+	}, []byte("software: github.com/umahmood/pinboard "+Version()+"\r\nformat: WARC File Format 1.1\r\n"))
+	for _, r := range responses {
+		if r.err != nil {
+			continue
+		}
+		var block bytes.Buffer
+		proto := r.proto
+		if proto == "" {
+			proto = "HTTP/1.1"
+		}
+		fmt.Fprintf(&block, "%s %d %s\r\n", proto, r.status, http.StatusText(r.status))
+		r.header.Write(&block)
+		block.WriteString("\r\n")
+		block.Write(r.body)
+		sum := sha1.Sum(r.body)
+		writeWARCRecord(w, "response", date, [][2]string{
+			{"WARC-Target-URI", r.url},
+			{"Content-Type", "application/http; msgtype=response"},
+			{"WARC-Payload-Digest", "sha1:" + base32.StdEncoding.EncodeToString(sum[:])},
+		}, block.Bytes())
+	}
+}
@@ -0,0 +1,124 @@
+package pinboard
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrNotAuthorized is returned, wrapped in an *AuthError, by requests made
+// before the client is authenticated.
+var ErrNotAuthorized = errors.New("API not authorized")
+
+// AuthError is returned when a request fails because the client is not
+// authenticated or Pinboard rejected its token. Err is ErrNotAuthorized or a
+// *StatusError for HTTP 401, the message is that of Err.
+type AuthError struct {
+	User string // User name of the token, the token itself is never kept.
+	Err  error
+}
+
+func (e *AuthError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns Err.
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// tokenUser returns the user name of token.
+func tokenUser(token string) string {
+	if i := strings.Index(token, ":"); i >= 0 {
+		return token[:i]
+	}
+	return ""
+}
+
+// user returns the user name of the clients token.
+func (p *Pinboard) user() string {
+	return tokenUser(p.Token())
+}
+
+// OnAuthExpired sets fn to be called for a fresh token once Pinboard rejects
+// the token with HTTP 401, e.g. after the user reset it. fn is given the
+// rejected token and returns the token to use instead, the request which was
+// rejected is then retried once with it. If fn returns an error or the fresh
+// token is rejected too, the client is left unauthenticated and fn is called
+// again by the next request. A client which is not authenticated also calls
+// fn before making a request, with an empty token if it never had one, so fn
+// can supply the first token too.
+//
+// Only one call to fn is made at a time, requests needing a token wait for it
+// to return. So fn must not make requests with the client, they would wait
+// for fn and deadlock, with the exception of Auth, which fn may use to check
+// the fresh token. The other methods which make no requests, such as Token
+// and the setters, are safe to call.
+func (p *Pinboard) OnAuthExpired(fn func(expired string) (string, error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onAuthExpired = fn
+}
+
+// isUnauthorized reports whether err is a HTTP 401 response.
+func isUnauthorized(err error) bool {
+	var s *StatusError
+	return errors.As(err, &s) && s.Code == http.StatusUnauthorized
+}
+
+// markExpired marks the client unauthenticated if its token is still token,
+// reporting whether it was.
+func (p *Pinboard) markExpired(token string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != token {
+		return false
+	}
+	p.authed = false
+	return true
+}
+
+// authExpired marks the client unauthenticated after token was rejected and
+// asks OnAuthExpired for a fresh one. Reports whether there is a fresh token
+// to retry with.
+func (p *Pinboard) authExpired(token string) bool {
+	p.refresh.Lock()
+	defer p.refresh.Unlock()
+	if !p.markExpired(token) {
+		// another goroutine has already replaced the token.
+		return p.IsAuthed()
+	}
+	return p.renew(token)
+}
+
+// reauth asks OnAuthExpired for a token when the client is unauthenticated,
+// reporting whether it now is authenticated.
+func (p *Pinboard) reauth() bool {
+	p.refresh.Lock()
+	defer p.refresh.Unlock()
+	if p.IsAuthed() {
+		return true
+	}
+	return p.renew(p.Token())
+}
+
+// renew replaces expired with a token from OnAuthExpired, reporting whether
+// it did. p.refresh must be held, so other requests wait for the fresh token
+// rather than asking for one too, but p.mu must not be as fn may use it.
+func (p *Pinboard) renew(expired string) bool {
+	p.mu.RLock()
+	fn := p.onAuthExpired
+	p.mu.RUnlock()
+	if fn == nil {
+		return false
+	}
+	fresh, err := fn(expired)
+	if err != nil || fresh == "" {
+		return false
+	}
+	p.mu.Lock()
+	p.token = fresh
+	p.authed = true
+	p.mu.Unlock()
+	return true
+}
@@ -0,0 +1,186 @@
+package pinboard
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// SortKey is what a list is sorted by.
+type SortKey int
+
+// Keys lists can be sorted by.
+const (
+	ByName SortKey = iota
+	ByCount
+	ByDate
+)
+
+// Sort is the order a list is returned in. The zero value sorts by name in
+// ascending order. Ties are broken by name, or by date for posts, so sorting
+// is deterministic.
+type Sort struct {
+	By   SortKey
+	Desc bool
+}
+
+// TagList is a list of tags with helpers to query it.
+type TagList []Tag
+
+// less reports whether tags i and j are in the order s.
+func (l TagList) less(s Sort, i, j int) bool {
+	a, b := l[i], l[j]
+	if s.By == ByCount && a.Count != b.Count {
+		if s.Desc {
+			return a.Count > b.Count
+		}
+		return a.Count < b.Count
+	}
+	if s.Desc && s.By != ByCount {
+		return a.Name > b.Name
+	}
+	return a.Name < b.Name
+}
+
+// Sort sorts the list in place in the order s, ByDate sorts by name as tags
+// have no date. Returns the list.
+func (l TagList) Sort(s Sort) TagList {
+	sort.SliceStable(l, func(i, j int) bool { return l.less(s, i, j) })
+	return l
+}
+
+// Top returns the n most used tags, most used first. Returns all tags if
+// there are fewer than n, and none if n <= 0.
+func (l TagList) Top(n int) TagList {
+	c := append(TagList(nil), l...).Sort(Sort{By: ByCount, Desc: true})
+	if n < 0 {
+		n = 0
+	}
+	if n < len(c) {
+		c = c[:n]
+	}
+	return c
+}
+
+// MinCount returns the tags used at least n times, in the same order.
+func (l TagList) MinCount(n int) TagList {
+	var f TagList
+	for _, t := range l {
+		if t.Count >= n {
+			f = append(f, t)
+		}
+	}
+	return f
+}
+
+// Find returns the tag called name, like Pinboard names are compared case
+// insensitively.
+func (l TagList) Find(name string) (Tag, bool) {
+	for _, t := range l {
+		if strings.EqualFold(t.Name, name) {
+			return t, true
+		}
+	}
+	return Tag{}, false
+}
+
+// Names returns the names of the tags, in the same order.
+func (l TagList) Names() []string {
+	var n []string
+	for _, t := range l {
+		n = append(n, t.Name)
+	}
+	return n
+}
+
+// PostList is a list of the number of posts on each date with helpers to
+// query it.
+type PostList []Post
+
+// less reports whether posts i and j are in the order s.
+func (l PostList) less(s Sort, i, j int) bool {
+	a, b := l[i], l[j]
+	if s.By == ByCount && a.Count != b.Count {
+		if s.Desc {
+			return a.Count > b.Count
+		}
+		return a.Count < b.Count
+	}
+	if s.Desc && s.By != ByCount {
+		return a.Date.After(b.Date)
+	}
+	return a.Date.Before(b.Date)
+}
+
+// Sort sorts the list in place in the order s, ByName sorts by date as posts
+// have no name. Returns the list.
+func (l PostList) Sort(s Sort) PostList {
+	sort.SliceStable(l, func(i, j int) bool { return l.less(s, i, j) })
+	return l
+}
+
+// Top returns the n dates with the most posts, busiest first. Returns all
+// dates if there are fewer than n, and none if n <= 0.
+func (l PostList) Top(n int) PostList {
+	c := append(PostList(nil), l...).Sort(Sort{By: ByCount, Desc: true})
+	if n < 0 {
+		n = 0
+	}
+	if n < len(c) {
+		c = c[:n]
+	}
+	return c
+}
+
+// MinCount returns the dates with at least n posts, in the same order.
+func (l PostList) MinCount(n int) PostList {
+	var f PostList
+	for _, p := range l {
+		if p.Count >= n {
+			f = append(f, p)
+		}
+	}
+	return f
+}
+
+// Find returns the number of posts on the day of date.
+func (l PostList) Find(date time.Time) (Post, bool) {
+	d := truncateDay(date)
+	for _, p := range l {
+		if truncateDay(p.Date).Equal(d) {
+			return p, true
+		}
+	}
+	return Post{}, false
+}
+
+// Total returns the number of posts on all dates.
+func (l PostList) Total() int {
+	n := 0
+	for _, p := range l {
+		n += p.Count
+	}
+	return n
+}
+
+// SortBookmarks sorts bookmarks in place in the order s: ByName sorts by
+// title, ByDate by creation time and ByCount by number of tags. Ties are
+// broken by URL.
+func SortBookmarks(bmarks []Bookmark, s Sort) {
+	sort.SliceStable(bmarks, func(i, j int) bool {
+		a, b := bmarks[i], bmarks[j]
+		var less, greater bool
+		switch s.By {
+		case ByName:
+			less, greater = a.Title < b.Title, a.Title > b.Title
+		case ByDate:
+			less, greater = a.Created.Before(b.Created), a.Created.After(b.Created)
+		case ByCount:
+			less, greater = len(a.Tags) < len(b.Tags), len(a.Tags) > len(b.Tags)
+		}
+		if less || greater {
+			return less != s.Desc
+		}
+		return a.URL < b.URL
+	})
+}
@@ -0,0 +1,169 @@
+package pinboard
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// CanonicalURL returns the canonical form of a URL, two bookmarks whose URLs
+// have the same canonical form point to the same page. The scheme, "www."
+// prefix, default ports, trailing slashes, fragments and tracking parameters
+// are ignored, remaining query parameters are sorted. Opaque URLs such as
+// mailto: links and javascript: bookmarklets are returned unchanged, other
+// URLs without a host return an error.
+func CanonicalURL(rawurl string) (string, error) {
+	rawurl = strings.TrimSpace(rawurl)
+	if u, err := url.Parse(rawurl); err == nil && u.Opaque != "" {
+		return rawurl, nil
+	}
+	n, err := DefaultURLPolicy.Normalize(rawurl)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(n)
+	if err != nil {
+		return "", err
+	}
+	if u.Host == "" {
+		return "", errors.New("url has no host: " + rawurl)
+	}
+	host := strings.TrimPrefix(u.Host, "www.")
+	path := strings.TrimRight(u.EscapedPath(), "/")
+	c := host + path
+	if q := u.Query(); len(q) > 0 {
+		// Encode sorts by key.
+		c += "?" + q.Encode()
+	}
+	return c, nil
+}
+
+// Duplicates groups bookmarks whose URLs have the same canonical form. Only
+// groups with more than one bookmark are returned, groups are ordered by the
+// first appearance of one of their bookmarks in bmarks. Bookmarks whose URL
+// has no canonical form, e.g. has no host, are never duplicates.
+func Duplicates(bmarks []Bookmark) [][]Bookmark {
+	var keys []string
+	groups := make(map[string][]Bookmark)
+	for _, b := range bmarks {
+		k, err := CanonicalURL(b.URL)
+		if err != nil {
+			// can't be compared, so it can't be a duplicate.
+			continue
+		}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], b)
+	}
+	var dups [][]Bookmark
+	for _, k := range keys {
+		if len(groups[k]) > 1 {
+			dups = append(dups, groups[k])
+		}
+	}
+	return dups
+}
+
+// MergePlan describes how a group of duplicate bookmarks is collapsed into a
+// single bookmark.
+type MergePlan struct {
+	// Keep is the merged bookmark which replaces the group.
+	Keep Bookmark
+	// Delete lists the URLs of the bookmarks removed by the merge.
+	Delete []string
+}
+
+// PlanMerge returns a plan to merge a group of duplicate bookmarks, as returned
+// by Duplicates. The merged bookmark keeps the URL, title and creation time of
+// the earliest bookmark in the group, the union of all tags and every distinct
+// description. It is shared only if all bookmarks are shared and marked unread
+// if any of them are.
+func PlanMerge(group []Bookmark) MergePlan {
+	if len(group) == 0 {
+		return MergePlan{}
+	}
+	sorted := make([]Bookmark, len(group))
+	copy(sorted, group)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Created.Before(sorted[j].Created)
+	})
+
+	keep := Bookmark{
+		URL:     sorted[0].URL,
+		Title:   sorted[0].Title,
+		Created: sorted[0].Created,
+		Replace: true,
+		Shared:  true,
+	}
+	seenTag := make(map[string]bool)
+	seenDesc := make(map[string]bool)
+	var descs []string
+	for _, b := range sorted {
+		if keep.Title == "" {
+			keep.Title = b.Title
+		}
+		for _, t := range b.Tags {
+			if t != "" && !seenTag[t] {
+				seenTag[t] = true
+				keep.Tags = append(keep.Tags, t)
+			}
+		}
+		d := strings.TrimSpace(b.Desc)
+		if d != "" && !seenDesc[d] {
+			seenDesc[d] = true
+			descs = append(descs, d)
+		}
+		keep.Shared = keep.Shared && b.Shared
+		keep.ToRead = keep.ToRead || b.ToRead
+	}
+	keep.Desc = strings.Join(descs, "\n\n")
+
+	plan := MergePlan{Keep: keep}
+	for _, b := range sorted[1:] {
+		if b.URL != keep.URL {
+			plan.Delete = append(plan.Delete, b.URL)
+		}
+	}
+	return plan
+}
+
+// Merge applies a merge plan, the merged bookmark is added before any of the
+// duplicates are deleted, so a failure never loses data. The URLs in
+// plan.Delete are deleted exactly as given, as stored by Pinboard, ignoring
+// the clients URL policy. If the policy changes the URL of plan.Keep, the
+// bookmark at its original URL is deleted too. Returns true if all operations
+// succeed.
+func (p *Pinboard) Merge(plan MergePlan) (bool, error) {
+	keep, err := p.normalizeURL(plan.Keep.URL)
+	if err != nil {
+		return false, err
+	}
+	ok, err := p.Add(plan.Keep)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, nil
+	}
+	del := plan.Delete
+	if keep != plan.Keep.URL {
+		del = append([]string{plan.Keep.URL}, del...)
+	}
+	seen := map[string]bool{keep: true} // never delete the merged bookmark.
+	for _, u := range del {
+		if seen[u] {
+			continue
+		}
+		seen[u] = true
+		ok, err := p.del(u)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
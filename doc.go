@@ -13,6 +13,43 @@ To start, you must authenticate using the users API token:
     token, err := pin.Auth("username:TOKEN")
     ...
 
+Or skip the round trip Auth makes and let the first request prove the token,
+a rejected token gives an *AuthError:
+
+    pin := pinboard.NewWithToken("username:TOKEN")
+
+A Pinboard is safe for concurrent use by multiple goroutines. Calling Auth
+again rotates the token, requests already in flight finish with the old one.
+
+Keep a long running process going after the user resets their token, the
+request which failed with HTTP 401 is retried with the fresh token:
+
+    pin.OnAuthExpired(func(expired string) (string, error) {
+        return readTokenFromVault()
+    })
+
+Optionally normalize URLs passed to Add, Del and Get, so that e.g.
+"https://eff.org" and "https://eff.org/" refer to the same bookmark:
+
+    pin.SetURLPolicy(&pinboard.DefaultURLPolicy)
+
+Bookmarks with descriptions too long for a URL are sent as form encoded POSTs,
+to POST every add, delete and rename instead:
+
+    pin.SetPostMode(pinboard.PostAlways)
+
+Log every request with log/slog, the auth token is redacted from URLs and
+errors. MetricsHooks and TracingHooks record metrics and traces, and
+MultiHooks combines hooks:
+
+    pin.SetHooks(pinboard.SlogHooks{Logger: slog.Default()})
+
+Record real responses once and replay them in tests, the auth token is
+scrubbed from the cassette:
+
+    rec := &pinboard.Recorder{Path: "testdata/recent.json", Mode: pinboard.Record}
+    pin.SetHTTPClient(&http.Client{Transport: rec})
+
 Last time users account had activity:
 
     t, err := pin.LastUpdate()
@@ -45,6 +82,45 @@ Add a bookmark:
         fmt.Println("bookmark added!")
     }
 
+Add a bookmark, filling in the title and description from the page and
+merging in popular tags:
+
+    b := pinboard.Bookmark{URL: "https://www.eff.org/"}
+
+    ok, err := pin.AddEnriched(pinboard.Fetcher{}, b, true)
+    ...
+
+Change a single field of a bookmark, keeping all other fields:
+
+    err := pin.Update("https://www.eff.org/", func(b *pinboard.Bookmark) {
+        b.Tags = append(b.Tags, "eff")
+    })
+    ...
+
+Change a bookmark only if it is unchanged since it was fetched, b is the
+bookmark as fetched with its change detection signature:
+
+    err := pin.UpdateIf(b.URL, b.Meta, func(b *pinboard.Bookmark) {
+        b.ToRead = false
+    })
+
+    if err == pinboard.ErrConflict {
+        // bookmark was changed elsewhere, fetch it and try again.
+    }
+
+Import bookmarks exported from Pocket, Raindrop.io, Firefox, Chrome or a
+Markdown list, trying a dry run first:
+
+    f, err := os.Open("Bookmarks")
+    ...
+
+    bmarks, err := pinboard.ImportChrome(f)
+    ...
+
+    opt := pinboard.ImportOptions{DryRun: true, Tags: []string{"imported"}}
+    report := pin.Import(bmarks, opt)
+    fmt.Println(len(report.Added), "to add", len(report.Skipped), "skipped")
+
 Deleting a bookmark:
 
     ok, err := pin.Del("https://www.eff.org/")
@@ -57,6 +133,17 @@ Deleting a bookmark:
         fmt.Println("bookmark deleted!")
     }
 
+Find duplicate bookmarks and merge them:
+
+    bmarks, err := pin.Bookmarks(nil, 0, 0, time.Time{}, time.Time{}, false)
+    ...
+
+    for _, group := range pinboard.Duplicates(bmarks) {
+        plan := pinboard.PlanMerge(group)
+        ok, err := pin.Merge(plan)
+        ...
+    }
+
 Get the bookmarks added today:
 
     bmarks, err := pin.Get(time.Time{}, "", nil, false)
@@ -69,6 +156,20 @@ Get the bookmarks added today:
         fmt.Println(b.Title)
     }
 
+Watch the users account and POST signed webhooks for every change:
+
+    w := pinboard.Watcher{Pinboard: pin,
+        Webhook: "https://chat.example.com/hooks/pinboard",
+        Secret:  []byte("s3cret"),
+    }
+
+    for e := range w.Watch(context.Background()) {
+        fmt.Println(e.Type, e.Bookmark.URL)
+    }
+
+Receivers check webhooks with VerifySignature(secret, body,
+r.Header.Get(pinboard.SignatureHeader)).
+
 Get bookmarks added on April 1st 2015:
 
     date := time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC)
@@ -106,6 +207,22 @@ Number of posts on each date for given tags:
     posts, err := pin.Dates(tags)
     ...
 
+Posting activity statistics and a calendar heatmap of the last year:
+
+    posts, err := pin.Dates(nil)
+    ...
+
+    a := pinboard.Summarize(posts, time.Now())
+    fmt.Println("Average posts per day:", a.Average)
+    fmt.Println("Longest streak:", a.LongestStreak, "days")
+
+    for _, m := range pinboard.Aggregate(posts, pinboard.Month) {
+        fmt.Println(m.Date.Format("2006-01"), m.Count)
+    }
+
+    err = pinboard.WriteHeatmapText(os.Stdout, posts, time.Now())
+    ...
+
 Users 5 most recent posts:
 
     bmarks, err := pin.Recent(nil, 5)
@@ -124,6 +241,51 @@ Users most recent posts filtered by tag:
     bmarks, err := pin.Recent(tags, 0)
     ...
 
+Publish the users most recent posts as an RSS, Atom or JSON feed:
+
+    bmarks, err := pin.Recent([]string{"team"}, 20)
+    ...
+
+    f := pinboard.Feed{Title: "Team links", Bookmarks: bmarks}
+    err = f.WriteAtom(os.Stdout)
+    ...
+
+Or serve the feed over HTTP, e.g. /feed?tag=team&format=atom:
+
+    http.Handle("/feed", pinboard.FeedHandler{Pinboard: pin, Title: "Links"})
+
+Read another users public bookmarks, no API token is needed:
+
+    bmarks, err := pinboard.PublicFeed(pinboard.FeedQuery{User: "mango",
+        Tags: []string{"golang"}})
+    ...
+
+Read the users private feed, including private bookmarks, with their secret
+RSS key:
+
+    u, err := pin.User()
+    ...
+
+    bmarks, err := pinboard.PublicFeed(u.Feed("golang"))
+    ...
+
+Copy bookmarks tagged "team" between a team account and a personal account,
+later runs only copy bookmarks which changed:
+
+    team := pinboard.New()
+    _, err := team.Auth("team:TOKEN")
+    ...
+
+    m := pinboard.Mirror{Src: team,
+        Dst:       pin,
+        Tags:      []string{"team"},
+        Direction: pinboard.TwoWay,
+        StatePath: "mirror.json",
+    }
+
+    report, err := m.Sync()
+    ...
+
 Get all bookmarks in the users account:
 
     bmarks, err := pin.Bookmarks(nil, 0, 0, time.Time{}, time.Time{}, false)
@@ -136,6 +298,51 @@ Get all bookmarks in the users account:
         fmt.Println(b.Title)
     }
 
+Export all bookmarks as JSON Lines for analysis, streaming them from Pinboard
+so they are never all held in memory:
+
+    opt := pinboard.ExportOptions{Format: pinboard.JSONLines, Tags: pinboard.TagsJSON}
+    w := pinboard.NewRecordWriter(os.Stdout, opt)
+
+    err := pin.StreamBookmarks(nil, time.Time{}, time.Time{}, false, w.WriteBookmark)
+    ...
+
+    err = w.Flush()
+    ...
+
+Save a local snapshot of every bookmarked page, pages already archived are
+skipped:
+
+    a := pinboard.Archiver{Dir: "archive", Format: pinboard.SingleFile}
+
+    statuses, err := a.ArchiveAll(bmarks)
+    ...
+
+    for _, s := range statuses {
+        if !s.OK() {
+            fmt.Println(s.URL, s.Error)
+        }
+    }
+
+Extract the readable text of bookmarked pages and search inside them:
+
+    f := pinboard.Fetcher{}
+    store := pinboard.TextStore{Dir: "archive"}
+    idx := pinboard.NewIndex()
+
+    for _, b := range bmarks {
+        a, err := f.Article(b.URL)
+        if err == nil {
+            err = store.Save(b, a)
+            ...
+        }
+        idx.Add(b, a)
+    }
+
+    for _, r := range idx.Search("race detector", 10) {
+        fmt.Println(r.Title, r.URL)
+    }
+
 Get 10 days worth of bookmarks:
 
     start := time.Now().AddDate(0, 0, -10)
@@ -143,6 +350,19 @@ Get 10 days worth of bookmarks:
     bmarks, err := pin.Bookmarks(nil, 0, 0, start, end, false)
     ...
 
+Page through all bookmarks 100 at a time, resuming from where a previous run
+stopped. Pages are five minutes apart, the rate limit of posts/all:
+
+    pg := &pinboard.Pager{Pinboard: pin, PageSize: 100, StatePath: "pager.json"}
+
+    for {
+        page, err := pg.Next()
+        if err == io.EOF {
+            break
+        }
+        ...
+    }
+
 Get all bookmarks which have the tags:
 
     tags := []string{"ruby", "tutorials"}
@@ -167,6 +387,37 @@ Get suggestions for tags based on a URL:
         fmt.Println(i)
     }
 
+Recommend tags for a URL from the users own bookmarks, merged with the
+suggestions from Pinboard:
+
+    bmarks, err := pin.Bookmarks(nil, 0, 0, time.Time{}, time.Time{}, false)
+    ...
+
+    r := pinboard.NewRecommender(bmarks)
+    b := pinboard.Bookmark{URL: "https://www.eff.org/", Title: "EFF"}
+    local := r.Recommend(b, 10)
+
+    pop, rec, err := pin.Suggest(b.URL)
+    ...
+
+    for _, t := range pinboard.MergeSuggestions(local, pop, rec, 5) {
+        fmt.Println(t.Name, t.Score)
+    }
+
+Work through the bookmarks marked as unread, oldest first:
+
+    r, err := pinboard.NewReadingList(pin, "reading.json")
+    ...
+
+    unread, err := r.Unread(nil, pinboard.OldestFirst)
+    ...
+
+    for _, b := range unread {
+        fmt.Println(b.Title)
+        err := r.MarkRead(b.URL)
+        ...
+    }
+
 Get a list of all tags in the users account:
 
     tags, err := pin.Tags()
@@ -179,6 +430,34 @@ Get a list of all tags in the users account:
         fmt.Println("Name:", t.Name, "# of tagged:", t.Count)
     }
 
+Get the ten most used tags:
+
+    tags, err := pin.Tags(pinboard.Sort{By: pinboard.ByCount, Desc: true})
+    ...
+
+    for _, t := range tags.Top(10) {
+        fmt.Println(t.Name, t.Count)
+    }
+
+Find tags used once and tags which are probably the same tag:
+
+    tags, err := pin.Tags()
+    ...
+
+    fmt.Println("Singletons:", pinboard.Singletons(tags))
+
+    for _, group := range pinboard.SimilarTags(tags, 1) {
+        fmt.Println("Similar:", group)
+    }
+
+Write the tag co-occurrence graph in the Graphviz DOT language:
+
+    bmarks, err := pin.Bookmarks(nil, 0, 0, time.Time{}, time.Time{}, false)
+    ...
+
+    err = pinboard.NewTagGraph(bmarks).WriteDOT(os.Stdout)
+    ...
+
 Delete a tag:
 
     ok, err := pin.DelTag("fonts")
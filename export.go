@@ -0,0 +1,528 @@
+package pinboard
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is a file format bookmarks and other data can be exported to.
+type Format int
+
+// Export formats.
+const (
+	// CSV with a header row.
+	CSV Format = iota
+	// JSONLines writes one JSON object per line.
+	JSONLines
+)
+
+// TagEncoding is how the list of tags of a bookmark is written.
+type TagEncoding int
+
+// Tag encodings.
+const (
+	// TagsSpace joins tags with spaces, as Pinboard does.
+	TagsSpace TagEncoding = iota
+	// TagsComma joins tags with commas.
+	TagsComma
+	// TagsJSON writes tags as a JSON array, in CSV the array is the value of
+	// the tags column.
+	TagsJSON
+)
+
+// ExportOptions controls how records are written and read.
+type ExportOptions struct {
+	Format Format
+	Tags   TagEncoding
+}
+
+// Column names of each kind of record, also the keys of JSON Lines objects.
+// Times are written as RFC3339 in UTC, zero times as an empty CSV value or a
+// JSON null.
+var (
+	bookmarkColumns = []string{"url", "title", "description", "tags", "created",
+		"shared", "toread", "hash", "meta"}
+	tagColumns  = []string{"name", "count"}
+	postColumns = []string{"date", "count"}
+	noteColumns = []string{"id", "title", "length", "hash", "created", "updated"}
+)
+
+// record is a bookmark, tag, post or note as a row keyed by column name. Values
+// are strings, bools, ints, tag lists or nil.
+type record map[string]interface{}
+
+// formatTime formats t as RFC3339 in UTC, the zero time as nil.
+func formatTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// RecordWriter writes bookmarks, tags, posts or notes as CSV or JSON Lines as
+// they come, so exports never need to be held in memory. A RecordWriter
+// writes a single kind of record, the CSV header is written with the first.
+type RecordWriter struct {
+	opt     ExportOptions
+	w       io.Writer
+	csv     *csv.Writer
+	columns []string
+}
+
+// NewRecordWriter returns a RecordWriter writing to w.
+func NewRecordWriter(w io.Writer, opt ExportOptions) *RecordWriter {
+	rw := &RecordWriter{opt: opt, w: w}
+	if opt.Format == CSV {
+		rw.csv = csv.NewWriter(w)
+	}
+	return rw
+}
+
+// encodeTags returns tags in the configured encoding.
+func (rw *RecordWriter) encodeTags(tags []string) (interface{}, error) {
+	tags = uniqueTags(tags)
+	switch rw.opt.Tags {
+	case TagsComma:
+		return strings.Join(tags, ","), nil
+	case TagsJSON:
+		if tags == nil {
+			tags = []string{}
+		}
+		if rw.csv == nil {
+			return tags, nil
+		}
+		data, err := json.Marshal(tags)
+		return string(data), err
+	}
+	return strings.Join(tags, " "), nil
+}
+
+// write writes rec with the given columns.
+func (rw *RecordWriter) write(columns []string, rec record) error {
+	if rw.columns == nil {
+		rw.columns = columns
+		if rw.csv != nil {
+			if err := rw.csv.Write(columns); err != nil {
+				return err
+			}
+		}
+	} else if rw.columns[0] != columns[0] {
+		return errors.New("record writer: can't mix kinds of records")
+	}
+	if rw.csv == nil {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		_, err = rw.w.Write(append(data, '\n'))
+		return err
+	}
+	row := make([]string, len(columns))
+	for i, c := range columns {
+		switch v := rec[c].(type) {
+		case nil:
+		case string:
+			row[i] = v
+		case bool:
+			row[i] = strconv.FormatBool(v)
+		case int:
+			row[i] = strconv.Itoa(v)
+		default:
+			row[i] = fmt.Sprint(v)
+		}
+	}
+	return rw.csv.Write(row)
+}
+
+// WriteBookmark writes a bookmark.
+func (rw *RecordWriter) WriteBookmark(b Bookmark) error {
+	tags, err := rw.encodeTags(b.Tags)
+	if err != nil {
+		return err
+	}
+	return rw.write(bookmarkColumns, record{"url": b.URL,
+		"title":       b.Title,
+		"description": b.Desc,
+		"tags":        tags,
+		"created":     formatTime(b.Created),
+		"shared":      b.Shared,
+		"toread":      b.ToRead,
+		"hash":        string(b.Hash),
+		"meta":        string(b.Meta),
+	})
+}
+
+// WriteTag writes a tag.
+func (rw *RecordWriter) WriteTag(t Tag) error {
+	return rw.write(tagColumns, record{"name": t.Name, "count": t.Count})
+}
+
+// WritePost writes a post.
+func (rw *RecordWriter) WritePost(p Post) error {
+	return rw.write(postColumns, record{"date": formatTime(p.Date), "count": p.Count})
+}
+
+// WriteNote writes the meta data of a note.
+func (rw *RecordWriter) WriteNote(n NoteMetadata) error {
+	return rw.write(noteColumns, record{"id": n.ID,
+		"title":   n.Title,
+		"length":  n.Length,
+		"hash":    string(n.Hash),
+		"created": formatTime(n.Created),
+		"updated": formatTime(n.Updated),
+	})
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (rw *RecordWriter) Flush() error {
+	if rw.csv == nil {
+		return nil
+	}
+	rw.csv.Flush()
+	return rw.csv.Error()
+}
+
+// RecordReader reads bookmarks, tags, posts or notes written by a
+// RecordWriter with the same options. CSV columns are found by name from the
+// header row, so they may come in any order and missing columns are left
+// zero.
+type RecordReader struct {
+	opt     ExportOptions
+	csv     *csv.Reader
+	json    *json.Decoder
+	columns map[string]int
+}
+
+// NewRecordReader returns a RecordReader reading from r.
+func NewRecordReader(r io.Reader, opt ExportOptions) *RecordReader {
+	rr := &RecordReader{opt: opt}
+	if opt.Format == CSV {
+		rr.csv = csv.NewReader(r)
+		rr.csv.FieldsPerRecord = -1
+	} else {
+		rr.json = json.NewDecoder(r)
+	}
+	return rr
+}
+
+// read returns the next record, or io.EOF when there are no more.
+func (rr *RecordReader) read() (record, error) {
+	if rr.json != nil {
+		var rec record
+		if err := rr.json.Decode(&rec); err != nil {
+			return nil, err
+		}
+		return rec, nil
+	}
+	if rr.columns == nil {
+		header, err := rr.csv.Read()
+		if err != nil {
+			return nil, err
+		}
+		rr.columns = make(map[string]int)
+		for i, h := range header {
+			rr.columns[strings.ToLower(strings.TrimSpace(h))] = i
+		}
+	}
+	row, err := rr.csv.Read()
+	if err != nil {
+		return nil, err
+	}
+	rec := make(record)
+	for c, i := range rr.columns {
+		if i < len(row) {
+			rec[c] = row[i]
+		}
+	}
+	return rec, nil
+}
+
+// str returns the value of column c as a string.
+func (rec record) str(c string) string {
+	switch v := rec[c].(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// boolean returns the value of column c as a bool.
+func (rec record) boolean(c string) (bool, error) {
+	switch v := rec[c].(type) {
+	case bool:
+		return v, nil
+	case nil:
+		return false, nil
+	}
+	s := rec.str(c)
+	if s == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("column %s: %v", c, err)
+	}
+	return b, nil
+}
+
+// integer returns the value of column c as an int.
+func (rec record) integer(c string) (int, error) {
+	switch v := rec[c].(type) {
+	case float64:
+		return int(v), nil
+	case nil:
+		return 0, nil
+	}
+	s := rec.str(c)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("column %s: %v", c, err)
+	}
+	return n, nil
+}
+
+// timestamp returns the value of column c as a time.
+func (rec record) timestamp(c string) (time.Time, error) {
+	s := rec.str(c)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("column %s: %v", c, err)
+	}
+	return t.UTC(), nil
+}
+
+// tags returns the value of column c as a list of tags in the encoding enc.
+func (rec record) tags(c string, enc TagEncoding) ([]string, error) {
+	if l, ok := rec[c].([]interface{}); ok {
+		var tags []string
+		for _, t := range l {
+			s, ok := t.(string)
+			if !ok {
+				return nil, fmt.Errorf("column %s: tag is not a string", c)
+			}
+			tags = append(tags, s)
+		}
+		return uniqueTags(tags), nil
+	}
+	s := rec.str(c)
+	switch enc {
+	case TagsComma:
+		return uniqueTags(strings.Split(s, ",")), nil
+	case TagsJSON:
+		if s == "" {
+			return nil, nil
+		}
+		var tags []string
+		if err := json.Unmarshal([]byte(s), &tags); err != nil {
+			return nil, fmt.Errorf("column %s: %v", c, err)
+		}
+		return uniqueTags(tags), nil
+	}
+	return uniqueTags(strings.Split(s, " ")), nil
+}
+
+// ReadBookmark reads the next bookmark, returns io.EOF when there are no more.
+func (rr *RecordReader) ReadBookmark() (Bookmark, error) {
+	rec, err := rr.read()
+	if err != nil {
+		return Bookmark{}, err
+	}
+	b := Bookmark{URL: rec.str("url"),
+		Title: rec.str("title"),
+		Desc:  rec.str("description"),
+	}
+	if h := rec.str("hash"); h != "" {
+		b.Hash = []byte(h)
+	}
+	if m := rec.str("meta"); m != "" {
+		b.Meta = []byte(m)
+	}
+	if b.Tags, err = rec.tags("tags", rr.opt.Tags); err != nil {
+		return Bookmark{}, err
+	}
+	if b.Created, err = rec.timestamp("created"); err != nil {
+		return Bookmark{}, err
+	}
+	if b.Shared, err = rec.boolean("shared"); err != nil {
+		return Bookmark{}, err
+	}
+	if b.ToRead, err = rec.boolean("toread"); err != nil {
+		return Bookmark{}, err
+	}
+	return b, nil
+}
+
+// ReadTag reads the next tag, returns io.EOF when there are no more.
+func (rr *RecordReader) ReadTag() (Tag, error) {
+	rec, err := rr.read()
+	if err != nil {
+		return Tag{}, err
+	}
+	t := Tag{Name: rec.str("name")}
+	if t.Count, err = rec.integer("count"); err != nil {
+		return Tag{}, err
+	}
+	return t, nil
+}
+
+// ReadPost reads the next post, returns io.EOF when there are no more.
+func (rr *RecordReader) ReadPost() (Post, error) {
+	rec, err := rr.read()
+	if err != nil {
+		return Post{}, err
+	}
+	var p Post
+	if p.Date, err = rec.timestamp("date"); err != nil {
+		return Post{}, err
+	}
+	if p.Count, err = rec.integer("count"); err != nil {
+		return Post{}, err
+	}
+	return p, nil
+}
+
+// ReadNote reads the meta data of the next note, returns io.EOF when there are
+// no more.
+func (rr *RecordReader) ReadNote() (NoteMetadata, error) {
+	rec, err := rr.read()
+	if err != nil {
+		return NoteMetadata{}, err
+	}
+	n := NoteMetadata{ID: rec.str("id"), Title: rec.str("title")}
+	if h := rec.str("hash"); h != "" {
+		n.Hash = []byte(h)
+	}
+	if n.Length, err = rec.integer("length"); err != nil {
+		return NoteMetadata{}, err
+	}
+	if n.Created, err = rec.timestamp("created"); err != nil {
+		return NoteMetadata{}, err
+	}
+	if n.Updated, err = rec.timestamp("updated"); err != nil {
+		return NoteMetadata{}, err
+	}
+	return n, nil
+}
+
+// WriteBookmarks writes bookmarks as CSV or JSON Lines.
+func WriteBookmarks(w io.Writer, bmarks []Bookmark, opt ExportOptions) error {
+	rw := NewRecordWriter(w, opt)
+	for _, b := range bmarks {
+		if err := rw.WriteBookmark(b); err != nil {
+			return err
+		}
+	}
+	return rw.Flush()
+}
+
+// WriteTags writes tags as CSV or JSON Lines.
+func WriteTags(w io.Writer, tags []Tag, opt ExportOptions) error {
+	rw := NewRecordWriter(w, opt)
+	for _, t := range tags {
+		if err := rw.WriteTag(t); err != nil {
+			return err
+		}
+	}
+	return rw.Flush()
+}
+
+// WritePosts writes posts as CSV or JSON Lines.
+func WritePosts(w io.Writer, posts []Post, opt ExportOptions) error {
+	rw := NewRecordWriter(w, opt)
+	for _, p := range posts {
+		if err := rw.WritePost(p); err != nil {
+			return err
+		}
+	}
+	return rw.Flush()
+}
+
+// WriteNotes writes the meta data of notes as CSV or JSON Lines.
+func WriteNotes(w io.Writer, notes []NoteMetadata, opt ExportOptions) error {
+	rw := NewRecordWriter(w, opt)
+	for _, n := range notes {
+		if err := rw.WriteNote(n); err != nil {
+			return err
+		}
+	}
+	return rw.Flush()
+}
+
+// ReadBookmarks reads all bookmarks written by WriteBookmarks.
+func ReadBookmarks(r io.Reader, opt ExportOptions) ([]Bookmark, error) {
+	rr := NewRecordReader(r, opt)
+	var bmarks []Bookmark
+	for {
+		b, err := rr.ReadBookmark()
+		if err == io.EOF {
+			return bmarks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		bmarks = append(bmarks, b)
+	}
+}
+
+// ReadTags reads all tags written by WriteTags.
+func ReadTags(r io.Reader, opt ExportOptions) ([]Tag, error) {
+	rr := NewRecordReader(r, opt)
+	var tags []Tag
+	for {
+		t, err := rr.ReadTag()
+		if err == io.EOF {
+			return tags, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+}
+
+// ReadPosts reads all posts written by WritePosts.
+func ReadPosts(r io.Reader, opt ExportOptions) ([]Post, error) {
+	rr := NewRecordReader(r, opt)
+	var posts []Post
+	for {
+		p, err := rr.ReadPost()
+		if err == io.EOF {
+			return posts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+}
+
+// ReadNotes reads the meta data of all notes written by WriteNotes.
+func ReadNotes(r io.Reader, opt ExportOptions) ([]NoteMetadata, error) {
+	rr := NewRecordReader(r, opt)
+	var notes []NoteMetadata
+	for {
+		n, err := rr.ReadNote()
+		if err == io.EOF {
+			return notes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+}
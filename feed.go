@@ -0,0 +1,371 @@
+package pinboard
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Feed represents a feed of bookmarks, which can be written as RSS 2.0, Atom
+// 1.0 or JSON Feed 1.1. Bookmarks can come from Recent, Get or Bookmarks or
+// any local query over them. Tags become categories and Desc the content of
+// each entry.
+type Feed struct {
+	Title string
+	Link  string // URL of the web page the feed is for.
+	Desc  string
+	// Author of the feed, Atom documents default to "Pinboard".
+	Author string
+	// Unique and permanent ID of the feed, default is Link or else a tag: URI
+	// made from Title.
+	ID string
+	// Time the feed was last updated, if zero the creation time of the newest
+	// bookmark is used.
+	Updated   time.Time
+	Bookmarks []Bookmark
+}
+
+// id returns the ID of the feed.
+func (f Feed) id() string {
+	if f.ID != "" {
+		return f.ID
+	}
+	if f.Link != "" {
+		return f.Link
+	}
+	return "tag:pinboard.in,2009:feed/" + url.PathEscape(f.Title)
+}
+
+// updated returns the time the feed was last updated.
+func (f Feed) updated() time.Time {
+	if !f.Updated.IsZero() {
+		return f.Updated.UTC()
+	}
+	var t time.Time
+	for _, b := range f.Bookmarks {
+		if b.Created.After(t) {
+			t = b.Created
+		}
+	}
+	return t.UTC()
+}
+
+// rss and friends describe an RSS 2.0 document.
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description,omitempty"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	GUID        rssGUID  `xml:"guid"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// writeXML writes v as an XML document.
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteRSS writes the feed as an RSS 2.0 document.
+func (f Feed) WriteRSS(w io.Writer) error {
+	doc := rss{Version: "2.0",
+		Channel: rssChannel{Title: f.Title,
+			Link:        f.Link,
+			Description: f.Desc,
+		},
+	}
+	if u := f.updated(); !u.IsZero() {
+		doc.Channel.LastBuildDate = u.Format(time.RFC1123Z)
+	}
+	for _, b := range f.Bookmarks {
+		i := rssItem{Title: b.Title,
+			Link:        b.URL,
+			Description: b.Desc,
+			Categories:  uniqueTags(b.Tags),
+			GUID:        rssGUID{IsPermaLink: true, Value: b.URL},
+		}
+		if !b.Created.IsZero() {
+			i.PubDate = b.Created.UTC().Format(time.RFC1123Z)
+		}
+		doc.Channel.Items = append(doc.Channel.Items, i)
+	}
+	return writeXML(w, doc)
+}
+
+// atom and friends describe an Atom 1.0 document.
+type atom struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Link       atomLink       `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Content    *atomContent   `xml:"content,omitempty"`
+}
+
+// WriteAtom writes the feed as an Atom 1.0 document. The URL of each bookmark
+// is used as the ID of its entry.
+func (f Feed) WriteAtom(w io.Writer) error {
+	updated := f.updated()
+	doc := atom{XMLNS: "http://www.w3.org/2005/Atom",
+		ID:      f.id(),
+		Title:   f.Title,
+		Updated: updated.Format(time.RFC3339),
+		Author:  atomAuthor{Name: f.Author},
+	}
+	if f.Link != "" {
+		doc.Link = &atomLink{Href: f.Link}
+	}
+	if doc.Author.Name == "" {
+		doc.Author.Name = "Pinboard"
+	}
+	for _, b := range f.Bookmarks {
+		e := atomEntry{ID: b.URL,
+			Title:   b.Title,
+			Updated: updated.Format(time.RFC3339),
+			Link:    atomLink{Href: b.URL},
+		}
+		if !b.Created.IsZero() {
+			e.Updated = b.Created.UTC().Format(time.RFC3339)
+		}
+		for _, t := range uniqueTags(b.Tags) {
+			e.Categories = append(e.Categories, atomCategory{Term: t})
+		}
+		if b.Desc != "" {
+			e.Content = &atomContent{Type: "text", Value: b.Desc}
+		}
+		doc.Entries = append(doc.Entries, e)
+	}
+	return writeXML(w, doc)
+}
+
+// jsonFeed and jsonFeedItem describe a JSON Feed 1.1 document.
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Authors     []jsonFeedName `json:"authors,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedName struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	Title         string   `json:"title,omitempty"`
+	ContentText   string   `json:"content_text"`
+	DatePublished string   `json:"date_published,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// WriteJSON writes the feed as a JSON Feed 1.1 document.
+func (f Feed) WriteJSON(w io.Writer) error {
+	doc := jsonFeed{Version: "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		Description: f.Desc,
+		Items:       []jsonFeedItem{},
+	}
+	if f.Author != "" {
+		doc.Authors = []jsonFeedName{{Name: f.Author}}
+	}
+	for _, b := range f.Bookmarks {
+		i := jsonFeedItem{ID: b.URL,
+			URL:         b.URL,
+			Title:       b.Title,
+			ContentText: b.Desc,
+			Tags:        uniqueTags(b.Tags),
+		}
+		if !b.Created.IsZero() {
+			i.DatePublished = b.Created.UTC().Format(time.RFC3339)
+		}
+		doc.Items = append(doc.Items, i)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// feedFormats maps a feed format to its content type.
+var feedFormats = map[string]string{
+	"rss":  "application/rss+xml; charset=utf-8",
+	"atom": "application/atom+xml; charset=utf-8",
+	"json": "application/feed+json; charset=utf-8",
+}
+
+// FeedHandler is an http.Handler which serves a feed of the users most recent
+// bookmarks. The "tag" query parameter filters bookmarks by tag and the
+// "format" query parameter selects "rss" (the default), "atom" or "json".
+// Responses carry Last-Modified and ETag headers based on LastUpdate, so
+// clients polling the feed, or making HEAD requests, cost a single
+// posts/update request while nothing changes.
+type FeedHandler struct {
+	Pinboard *Pinboard
+	Title    string
+	Link     string
+	Desc     string
+	// Author of the feed, default is the user name.
+	Author string
+	// ID of the feed, see Feed.
+	ID string
+	// Number of bookmarks in the feed, default is 15 max is 100.
+	Count int
+	// Value of the Cache-Control header, default is "max-age=300".
+	CacheControl string
+}
+
+// ServeHTTP serves the feed.
+func (h FeedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "rss"
+	}
+	ct, ok := feedFormats[format]
+	if !ok {
+		http.Error(w, "unknown feed format: "+format, http.StatusBadRequest)
+		return
+	}
+	var tags []string
+	if t := strings.TrimSpace(r.URL.Query().Get("tag")); t != "" {
+		tags = strings.Fields(strings.Replace(t, ",", " ", -1))
+	}
+
+	last, err := h.Pinboard.LastUpdate()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	count := h.Count
+	if count == 0 {
+		count = 15
+	}
+	sum := md5.Sum([]byte(last.Format(time.RFC3339) + "|" + format + "|" +
+		strings.Join(tags, ",") + "|" + strconv.Itoa(count)))
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	cc := h.CacheControl
+	if cc == "" {
+		cc = "max-age=300"
+	}
+	w.Header().Set("Cache-Control", cc)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", last.UTC().Format(http.TimeFormat))
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etagMatch(inm, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	} else if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil &&
+		!last.Truncate(time.Second).After(ims) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Type", ct)
+		return
+	}
+
+	bmarks, err := h.Pinboard.Recent(tags, count)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	f := Feed{Title: h.Title,
+		Link:      h.Link,
+		Desc:      h.Desc,
+		Author:    h.Author,
+		ID:        h.ID,
+		Updated:   last,
+		Bookmarks: bmarks,
+	}
+	if f.Author == "" {
+		f.Author = h.Pinboard.user()
+	}
+	w.Header().Set("Content-Type", ct)
+	// once the body is being written errors can't be reported to the client.
+	switch format {
+	case "atom":
+		f.WriteAtom(w)
+	case "json":
+		f.WriteJSON(w)
+	default:
+		f.WriteRSS(w)
+	}
+}
+
+// etagMatch reports whether the If-None-Match header inm, a list of entity
+// tags, matches etag. Tags are compared weakly, ignoring any "W/" prefix.
+func etagMatch(inm, etag string) bool {
+	for _, t := range strings.Split(inm, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,357 @@
+package pinboard
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RequestInfo describes a request to the Pinboard API. The auth token is
+// always redacted from URL and Err.
+type RequestInfo struct {
+	ID         uint64 // Unique to the request, ties RequestEnd to RequestStart.
+	Method     string // API method, e.g. "posts/all".
+	HTTPMethod string // GET, or POST for write requests sent as forms.
+	URL        string
+	Start      time.Time
+
+	// Set when the request ends.
+	Status  int           // HTTP status code, 0 if there was no response.
+	Bytes   int64         // Bytes of the response body read.
+	Latency time.Duration // Time from the start to the end of the response body.
+	Err     error
+}
+
+// Hooks observe the requests a client makes. Hooks are called synchronously
+// by the goroutine making the request so must be quick, and must be safe for
+// concurrent use if the client is used concurrently. Embed NopHooks to only
+// implement some of them.
+type Hooks interface {
+	// RequestStart is called before a request is sent.
+	RequestStart(r RequestInfo)
+	// RequestEnd is called once the response body has been read, or the
+	// request failed.
+	RequestEnd(r RequestInfo)
+	// Retry is called before a failed request r is retried, attempt counts
+	// the retries from 1.
+	Retry(r RequestInfo, attempt int)
+	// RateLimitWait is called before waiting to request an API method so as
+	// not to break the Pinboard rate limits.
+	RateLimitWait(method string, wait time.Duration)
+}
+
+// NopHooks are hooks which do nothing.
+type NopHooks struct{}
+
+// RequestStart does nothing.
+func (NopHooks) RequestStart(r RequestInfo) {}
+
+// RequestEnd does nothing.
+func (NopHooks) RequestEnd(r RequestInfo) {}
+
+// Retry does nothing.
+func (NopHooks) Retry(r RequestInfo, attempt int) {}
+
+// RateLimitWait does nothing.
+func (NopHooks) RateLimitWait(method string, wait time.Duration) {}
+
+// multiHooks calls each of a list of hooks in turn.
+type multiHooks []Hooks
+
+// MultiHooks returns hooks which call each of hooks in turn.
+func MultiHooks(hooks ...Hooks) Hooks {
+	return multiHooks(hooks)
+}
+
+func (m multiHooks) RequestStart(r RequestInfo) {
+	for _, h := range m {
+		h.RequestStart(r)
+	}
+}
+
+func (m multiHooks) RequestEnd(r RequestInfo) {
+	for _, h := range m {
+		h.RequestEnd(r)
+	}
+}
+
+func (m multiHooks) Retry(r RequestInfo, attempt int) {
+	for _, h := range m {
+		h.Retry(r, attempt)
+	}
+}
+
+func (m multiHooks) RateLimitWait(method string, wait time.Duration) {
+	for _, h := range m {
+		h.RateLimitWait(method, wait)
+	}
+}
+
+// SetHooks sets the hooks called as the client makes requests. A nil Hooks,
+// the default, turns them off.
+func (p *Pinboard) SetHooks(h Hooks) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hooks = h
+}
+
+// requestID is the ID of the last request.
+var requestID uint64
+
+// redacted replaces auth tokens.
+const redacted = "REDACTED"
+
+// redactURL returns rawurl with the auth token redacted.
+func redactURL(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		// don't risk leaking the token in a query we can't parse.
+		if i := strings.Index(rawurl, "?"); i >= 0 {
+			return rawurl[:i]
+		}
+		return rawurl
+	}
+	q := u.Query()
+	if q.Get("auth_token") != "" {
+		q.Set("auth_token", redacted)
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+// redactError returns err with the auth token of the request to rawurl
+// redacted from its message.
+func redactError(err error, rawurl string) error {
+	if err == nil {
+		return nil
+	}
+	u, perr := url.Parse(rawurl)
+	if perr != nil {
+		return errors.New(redactURL(rawurl) + ": request failed")
+	}
+	token := u.Query().Get("auth_token")
+	msg := err.Error()
+	if token == "" || !strings.Contains(msg, token) &&
+		!strings.Contains(msg, url.QueryEscape(token)) {
+		return err
+	}
+	msg = strings.Replace(msg, url.QueryEscape(token), redacted, -1)
+	return errors.New(strings.Replace(msg, token, redacted, -1))
+}
+
+// send performs a HTTP GET on u, the URL of an API method, or a POST of its
+// query if post is set, reporting it to hooks. The caller must close the
+// response body.
+func send(client *http.Client, hooks Hooks, method, u string, post bool) (io.ReadCloser, error) {
+	req, err := newRequest(u, post)
+	if err != nil {
+		return nil, err
+	}
+	if hooks == nil {
+		r, _, err := doRequest(client, req)
+		return r, err
+	}
+	r := RequestInfo{ID: atomic.AddUint64(&requestID, 1),
+		Method:     method,
+		HTTPMethod: req.Method,
+		URL:        redactURL(req.URL.String()),
+		Start:      time.Now(),
+	}
+	hooks.RequestStart(r)
+	body, status, err := doRequest(client, req)
+	r.Status = status
+	if err != nil {
+		r.Latency = time.Since(r.Start)
+		r.Err = redactError(err, u)
+		hooks.RequestEnd(r)
+		return nil, err
+	}
+	return &hookedBody{ReadCloser: body, hooks: hooks, info: r, url: u}, nil
+}
+
+// hookedBody is a response body which calls RequestEnd when closed.
+type hookedBody struct {
+	io.ReadCloser
+	hooks  Hooks
+	info   RequestInfo
+	url    string
+	closed bool
+}
+
+func (b *hookedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.info.Bytes += int64(n)
+	if err != nil && err != io.EOF {
+		b.info.Err = redactError(err, b.url)
+	}
+	return n, err
+}
+
+func (b *hookedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if !b.closed {
+		b.closed = true
+		b.info.Latency = time.Since(b.info.Start)
+		b.hooks.RequestEnd(b.info)
+	}
+	return err
+}
+
+// rateLimitWait sleeps for wait before requesting method, reporting it to the
+// clients hooks.
+func (p *Pinboard) rateLimitWait(method string, wait time.Duration) {
+	if wait <= 0 {
+		return
+	}
+	p.mu.RLock()
+	hooks := p.hooks
+	p.mu.RUnlock()
+	if hooks != nil {
+		hooks.RateLimitWait(method, wait)
+	}
+	time.Sleep(wait)
+}
+
+// Counter is a counter with labels, such as a Prometheus CounterVec wrapped
+// as:
+//
+//	type counter struct{ *prometheus.CounterVec }
+//
+//	func (c counter) Add(v float64, labels ...string) {
+//	    c.WithLabelValues(labels...).Add(v)
+//	}
+type Counter interface {
+	Add(v float64, labels ...string)
+}
+
+// Histogram is a histogram with labels, such as a Prometheus HistogramVec
+// wrapped like a Counter.
+type Histogram interface {
+	Observe(v float64, labels ...string)
+}
+
+// MetricsHooks are hooks which record metrics of requests. Metrics left nil
+// are not recorded. Every metric is labelled with the API method, Requests and
+// Latency are also labelled with the HTTP status code, "0" if there was no
+// response.
+type MetricsHooks struct {
+	Requests Counter   // Requests made.
+	Latency  Histogram // Seconds taken by requests.
+	Bytes    Counter   // Bytes of response bodies read.
+	Retries  Counter   // Requests retried.
+	Waits    Histogram // Seconds waited for rate limits.
+}
+
+// RequestStart does nothing, requests are recorded when they end.
+func (m MetricsHooks) RequestStart(r RequestInfo) {}
+
+// RequestEnd records a request.
+func (m MetricsHooks) RequestEnd(r RequestInfo) {
+	status := strconv.Itoa(r.Status)
+	if m.Requests != nil {
+		m.Requests.Add(1, r.Method, status)
+	}
+	if m.Latency != nil {
+		m.Latency.Observe(r.Latency.Seconds(), r.Method, status)
+	}
+	if m.Bytes != nil {
+		m.Bytes.Add(float64(r.Bytes), r.Method)
+	}
+}
+
+// Retry records a retry.
+func (m MetricsHooks) Retry(r RequestInfo, attempt int) {
+	if m.Retries != nil {
+		m.Retries.Add(1, r.Method)
+	}
+}
+
+// RateLimitWait records a wait.
+func (m MetricsHooks) RateLimitWait(method string, wait time.Duration) {
+	if m.Waits != nil {
+		m.Waits.Observe(wait.Seconds(), method)
+	}
+}
+
+// Span is a span of a trace, the subset of an OpenTelemetry trace.Span used by
+// TracingHooks.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts spans, like an OpenTelemetry trace.Tracer. The methods of the
+// client take no context, so a Tracer which wants spans to have a parent must
+// find it itself.
+type Tracer interface {
+	Start(name string) Span
+}
+
+// TracingHooks are hooks which trace each request as a span named
+// "pinboard <method>", with attributes following the OpenTelemetry HTTP
+// conventions. Retries and rate limit waits are traced as spans of their own.
+type TracingHooks struct {
+	Tracer Tracer
+
+	mu    sync.Mutex
+	spans map[uint64]Span // request ID -> span of request.
+}
+
+// RequestStart starts the span of a request.
+func (t *TracingHooks) RequestStart(r RequestInfo) {
+	s := t.Tracer.Start("pinboard " + r.Method)
+	s.SetAttribute("http.request.method", r.HTTPMethod)
+	s.SetAttribute("url.full", r.URL)
+	s.SetAttribute("pinboard.method", r.Method)
+	t.mu.Lock()
+	if t.spans == nil {
+		t.spans = make(map[uint64]Span)
+	}
+	t.spans[r.ID] = s
+	t.mu.Unlock()
+}
+
+// RequestEnd ends the span of a request.
+func (t *TracingHooks) RequestEnd(r RequestInfo) {
+	t.mu.Lock()
+	s, ok := t.spans[r.ID]
+	delete(t.spans, r.ID)
+	t.mu.Unlock()
+	if !ok {
+		return
+	}
+	if r.Status != 0 {
+		s.SetAttribute("http.response.status_code", r.Status)
+	}
+	s.SetAttribute("http.response.body.size", r.Bytes)
+	if r.Err != nil {
+		s.RecordError(r.Err)
+	}
+	s.End()
+}
+
+// Retry traces a retry.
+func (t *TracingHooks) Retry(r RequestInfo, attempt int) {
+	s := t.Tracer.Start("pinboard retry")
+	s.SetAttribute("pinboard.method", r.Method)
+	s.SetAttribute("http.request.resend_count", attempt)
+	if r.Err != nil {
+		s.RecordError(r.Err)
+	}
+	s.End()
+}
+
+// RateLimitWait traces a wait.
+func (t *TracingHooks) RateLimitWait(method string, wait time.Duration) {
+	s := t.Tracer.Start("pinboard rate limit wait")
+	s.SetAttribute("pinboard.method", method)
+	s.SetAttribute("pinboard.wait_seconds", wait.Seconds())
+	s.End()
+}
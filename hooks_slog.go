@@ -0,0 +1,71 @@
+//go:build go1.21
+
+package pinboard
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlogHooks are hooks which log to Logger, or slog.Default() if it is nil.
+// Requests are logged at Debug when they start and at Info when they end, or
+// at Warn if they failed. Retries are logged at Warn and rate limit waits at
+// Info.
+type SlogHooks struct {
+	Logger *slog.Logger
+}
+
+// logger returns the logger to log to.
+func (h SlogHooks) logger() *slog.Logger {
+	if h.Logger == nil {
+		return slog.Default()
+	}
+	return h.Logger
+}
+
+// RequestStart logs the start of a request.
+func (h SlogHooks) RequestStart(r RequestInfo) {
+	h.logger().Debug("pinboard request start",
+		slog.Uint64("id", r.ID),
+		slog.String("method", r.Method),
+		slog.String("url", r.URL))
+}
+
+// RequestEnd logs the end of a request.
+func (h SlogHooks) RequestEnd(r RequestInfo) {
+	level := slog.LevelInfo
+	attrs := []slog.Attr{
+		slog.Uint64("id", r.ID),
+		slog.String("method", r.Method),
+		slog.String("url", r.URL),
+		slog.Int("status", r.Status),
+		slog.Int64("bytes", r.Bytes),
+		slog.Duration("latency", r.Latency),
+	}
+	if r.Err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", r.Err.Error()))
+	}
+	h.logger().LogAttrs(context.Background(), level, "pinboard request end", attrs...)
+}
+
+// Retry logs a retry.
+func (h SlogHooks) Retry(r RequestInfo, attempt int) {
+	attrs := []slog.Attr{
+		slog.Uint64("id", r.ID),
+		slog.String("method", r.Method),
+		slog.Int("attempt", attempt),
+	}
+	if r.Err != nil {
+		attrs = append(attrs, slog.String("error", r.Err.Error()))
+	}
+	h.logger().LogAttrs(context.Background(), slog.LevelWarn, "pinboard request retry", attrs...)
+}
+
+// RateLimitWait logs a wait.
+func (h SlogHooks) RateLimitWait(method string, wait time.Duration) {
+	h.logger().Info("pinboard rate limit wait",
+		slog.String("method", method),
+		slog.Duration("wait", wait))
+}
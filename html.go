@@ -0,0 +1,203 @@
+package pinboard
+
+import (
+	"html"
+	"strings"
+)
+
+// htmlTokenType is the type of an htmlToken.
+type htmlTokenType int
+
+const (
+	htmlText htmlTokenType = iota
+	htmlStartTag
+	htmlEndTag
+)
+
+// htmlToken is a piece of an HTML document. start and end are the byte offsets
+// of the token in the document.
+type htmlToken struct {
+	typ   htmlTokenType
+	name  string            // lower case tag name.
+	attrs map[string]string // lower case attribute names, unescaped values.
+	text  string            // unescaped text, raw for script and style.
+	start int
+	end   int
+}
+
+// rawTextElements are elements whose content is not parsed as HTML.
+var rawTextElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"title":    true,
+	"textarea": true,
+}
+
+// tokenizeHTML splits an HTML document into text, start tag and end tag tokens.
+// Comments, doctypes and processing instructions are dropped. It is a lenient
+// scanner rather than a parser, just enough to pick metadata, links and text
+// out of real world pages.
+func tokenizeHTML(s string) []htmlToken {
+	var tokens []htmlToken
+	i := 0
+	for i < len(s) {
+		if s[i] != '<' {
+			j := strings.IndexByte(s[i:], '<')
+			if j < 0 {
+				j = len(s) - i
+			}
+			tokens = append(tokens, htmlToken{typ: htmlText,
+				text:  html.UnescapeString(s[i : i+j]),
+				start: i,
+				end:   i + j,
+			})
+			i += j
+			continue
+		}
+		switch {
+		case strings.HasPrefix(s[i:], "<!--"):
+			j := strings.Index(s[i+4:], "-->")
+			if j < 0 {
+				return tokens
+			}
+			i += 4 + j + 3
+			continue
+		case strings.HasPrefix(s[i:], "<!"), strings.HasPrefix(s[i:], "<?"):
+			j := strings.IndexByte(s[i:], '>')
+			if j < 0 {
+				return tokens
+			}
+			i += j + 1
+			continue
+		}
+		t, n := scanTag(s[i:])
+		if n == 0 {
+			// a lone '<' is text.
+			tokens = append(tokens, htmlToken{typ: htmlText, text: "<",
+				start: i, end: i + 1})
+			i++
+			continue
+		}
+		t.start, t.end = i, i+n
+		tokens = append(tokens, t)
+		i += n
+		if t.typ == htmlStartTag && rawTextElements[t.name] {
+			end := strings.Index(strings.ToLower(s[i:]), "</"+t.name)
+			if end < 0 {
+				end = len(s) - i
+			}
+			text := s[i : i+end]
+			if t.name == "title" || t.name == "textarea" {
+				text = html.UnescapeString(text)
+			}
+			tokens = append(tokens, htmlToken{typ: htmlText, text: text,
+				start: i, end: i + end})
+			i += end
+		}
+	}
+	return tokens
+}
+
+// scanTag scans a start or end tag at the beginning of s, returns the token
+// and its length in bytes, or a length of 0 if s does not start with a tag.
+func scanTag(s string) (htmlToken, int) {
+	t := htmlToken{typ: htmlStartTag}
+	i := 1
+	if i < len(s) && s[i] == '/' {
+		t.typ = htmlEndTag
+		i++
+	}
+	j := i
+	for j < len(s) && isTagNameByte(s[j]) {
+		j++
+	}
+	if j == i || !isLetter(s[i]) {
+		return htmlToken{}, 0
+	}
+	t.name = strings.ToLower(s[i:j])
+	i = j
+	for i < len(s) {
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		if s[i] == '>' {
+			return t, i + 1
+		}
+		if s[i] == '/' {
+			i++
+			continue
+		}
+		// attribute name.
+		j = i
+		for j < len(s) && !isSpace(s[j]) && s[j] != '=' && s[j] != '>' &&
+			s[j] != '/' {
+			j++
+		}
+		if j == i {
+			// stray character such as a quote, skip it.
+			i++
+			continue
+		}
+		name := strings.ToLower(s[i:j])
+		i = j
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		var val string
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				q := s[i]
+				j = strings.IndexByte(s[i+1:], q)
+				if j < 0 {
+					return htmlToken{}, 0
+				}
+				val = s[i+1 : i+1+j]
+				i += j + 2
+			} else {
+				j = i
+				for j < len(s) && !isSpace(s[j]) && s[j] != '>' {
+					j++
+				}
+				val = s[i:j]
+				i = j
+			}
+		}
+		if t.attrs == nil {
+			t.attrs = make(map[string]string)
+		}
+		if _, ok := t.attrs[name]; !ok {
+			t.attrs[name] = html.UnescapeString(val)
+		}
+	}
+	// unterminated tag.
+	return htmlToken{}, 0
+}
+
+// isTagNameByte returns true if c can be part of a tag name.
+func isTagNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == ':'
+}
+
+// isLetter returns true if c is an ASCII letter.
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isSpace returns true if c is HTML white space.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// collapseSpace replaces runs of white space in s with a single space and trims
+// leading and trailing white space.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
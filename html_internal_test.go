@@ -0,0 +1,83 @@
+package pinboard
+
+import "testing"
+
+// TOKENIZEHTML
+
+func TestTokenizeHTML(t *testing.T) {
+	in := `<!DOCTYPE html><!-- hi --><A HREF='/x?a=1&amp;b=2' data-x=y>Fish &amp; Chips</a>` +
+		`<script>if (a < b) {}</script><br/>1 < 2`
+
+	got := tokenizeHTML(in)
+
+	want := []htmlToken{
+		{typ: htmlStartTag, name: "a"},
+		{typ: htmlText, text: "Fish & Chips"},
+		{typ: htmlEndTag, name: "a"},
+		{typ: htmlStartTag, name: "script"},
+		{typ: htmlText, text: "if (a < b) {}"},
+		{typ: htmlEndTag, name: "script"},
+		{typ: htmlStartTag, name: "br"},
+		{typ: htmlText, text: "1 "},
+		{typ: htmlText, text: "<"},
+		{typ: htmlText, text: " 2"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("len: got %d want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].typ != want[i].typ || got[i].name != want[i].name ||
+			got[i].text != want[i].text {
+			t.Errorf("token %d: got %+v want %+v", i, got[i], want[i])
+		}
+	}
+	if got[0].attrs["href"] != "/x?a=1&b=2" {
+		t.Errorf("href: got %s want /x?a=1&b=2", got[0].attrs["href"])
+	}
+	if got[0].attrs["data-x"] != "y" {
+		t.Errorf("data-x: got %s want y", got[0].attrs["data-x"])
+	}
+	if in[got[0].start:got[0].end] != `<A HREF='/x?a=1&amp;b=2' data-x=y>` {
+		t.Errorf("offsets: got %s", in[got[0].start:got[0].end])
+	}
+}
+
+// PARSEPAGEMETA
+
+func TestParsePageMeta(t *testing.T) {
+	in := `<html><head>
+	<title>
+	  EFF | Electronic Frontier Foundation
+	</title>
+	<meta name="description" content="Defending your rights">
+	<link rel="canonical" href="/about">
+	</head><body><meta property="og:title" content="ignored"></body></html>`
+
+	got := parsePageMeta(in)
+
+	want := PageMeta{Title: "EFF | Electronic Frontier Foundation",
+		Desc:      "Defending your rights",
+		Canonical: "/about",
+	}
+	if got != want {
+		t.Errorf("page meta: got %+v want %+v", got, want)
+	}
+}
+
+func TestParsePageMetaOpenGraph(t *testing.T) {
+	in := `<head><title>EFF | Home</title>
+	<meta name="description" content="meta description">
+	<meta name="twitter:description" content="twitter description">
+	<meta property="og:title" content="Electronic Frontier Foundation">
+	</head>`
+
+	got := parsePageMeta(in)
+
+	want := PageMeta{Title: "Electronic Frontier Foundation",
+		Desc: "twitter description",
+	}
+	if got != want {
+		t.Errorf("page meta: got %+v want %+v", got, want)
+	}
+}
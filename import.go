@@ -0,0 +1,381 @@
+package pinboard
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// importTag turns a folder name, or a tag from another service, into a tag.
+// Tags can't contain spaces or commas, so runs of either become a dash.
+func importTag(name string) string {
+	f := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	return strings.Join(f, "-")
+}
+
+// importTags turns the folders a bookmark is in, or its tags from another
+// service, into tags.
+func importTags(names []string) []string {
+	var tags []string
+	for _, n := range names {
+		if t := importTag(n); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// ImportPocket reads bookmarks from a Pocket HTML export. Bookmarks in the
+// "Unread" section are marked to read.
+func ImportPocket(r io.Reader) ([]Bookmark, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		bmarks  []Bookmark
+		section string
+		inH1    bool
+		link    *Bookmark
+	)
+	for _, t := range tokenizeHTML(string(data)) {
+		switch {
+		case t.typ == htmlStartTag && t.name == "h1":
+			inH1, section = true, ""
+		case t.typ == htmlEndTag && t.name == "h1":
+			inH1 = false
+		case t.typ == htmlStartTag && t.name == "a" && t.attrs["href"] != "":
+			b := Bookmark{URL: t.attrs["href"],
+				ToRead: !strings.EqualFold(strings.TrimSpace(section), "read archive"),
+			}
+			if s := t.attrs["time_added"]; s != "" {
+				if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+					b.Created = time.Unix(n, 0).UTC()
+				}
+			}
+			if s := t.attrs["tags"]; s != "" {
+				b.Tags = uniqueTags(importTags(strings.Split(s, ",")))
+			}
+			link = &b
+		case t.typ == htmlEndTag && t.name == "a" && link != nil:
+			link.Title = collapseSpace(link.Title)
+			bmarks = append(bmarks, *link)
+			link = nil
+		case t.typ == htmlText && link != nil:
+			link.Title += t.text
+		case t.typ == htmlText && inH1:
+			section += t.text
+		}
+	}
+	return bmarks, nil
+}
+
+// ImportRaindrop reads bookmarks from a Raindrop.io CSV export. Columns are
+// found by name from the header row, nested folders ("Dev/Go") become one tag
+// per folder.
+func ImportRaindrop(r io.Reader) ([]Bookmark, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	col := make(map[string]int)
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := col["url"]; !ok {
+		return nil, errors.New("raindrop: no url column")
+	}
+	var bmarks []Bookmark
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(name string) string {
+			if i, ok := col[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		b := Bookmark{URL: field("url"),
+			Title: field("title"),
+			Desc:  firstNonEmpty(field("note"), field("excerpt")),
+		}
+		if b.URL == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, field("created")); err == nil {
+			b.Created = t.UTC()
+		}
+		tags := importTags(strings.Split(field("tags"), ","))
+		if f := field("folder"); f != "" && f != "Unsorted" {
+			tags = append(tags, importTags(strings.Split(f, "/"))...)
+		}
+		b.Tags = uniqueTags(tags)
+		bmarks = append(bmarks, b)
+	}
+	return bmarks, nil
+}
+
+// firefoxNode is a folder or bookmark in a Firefox JSON bookmark backup.
+type firefoxNode struct {
+	Title     string        `json:"title"`
+	Type      string        `json:"type"`
+	Root      string        `json:"root"`
+	URI       string        `json:"uri"`
+	DateAdded int64         `json:"dateAdded"` // microseconds since the epoch.
+	Tags      string        `json:"tags"`
+	Children  []firefoxNode `json:"children"`
+}
+
+// ImportFirefox reads bookmarks from a Firefox JSON bookmark backup, the JSON
+// form of places.sqlite written by "Backup" in the Library window. Folders
+// become tags, except the built in menu, toolbar and other bookmarks folders.
+func ImportFirefox(r io.Reader) ([]Bookmark, error) {
+	var root firefoxNode
+	if err := json.NewDecoder(r).Decode(&root); err != nil {
+		return nil, err
+	}
+	var bmarks []Bookmark
+	var walk func(n firefoxNode, folders []string)
+	walk = func(n firefoxNode, folders []string) {
+		switch n.Type {
+		case "text/x-moz-place-container":
+			if n.Root == "" {
+				folders = append(folders, n.Title)
+			}
+			for _, c := range n.Children {
+				walk(c, folders)
+			}
+		case "text/x-moz-place":
+			// place: URIs are smart folders, not bookmarks.
+			if n.URI == "" || strings.HasPrefix(n.URI, "place:") {
+				return
+			}
+			b := Bookmark{URL: n.URI,
+				Title: n.Title,
+				Tags:  uniqueTags(importTags(append(strings.Split(n.Tags, ","), folders...))),
+			}
+			if n.DateAdded > 0 {
+				b.Created = time.Unix(0, n.DateAdded*int64(time.Microsecond)).UTC()
+			}
+			bmarks = append(bmarks, b)
+		}
+	}
+	walk(root, nil)
+	return bmarks, nil
+}
+
+// chromeNode is a folder or bookmark in a Chrome Bookmarks file.
+type chromeNode struct {
+	Name      string       `json:"name"`
+	Type      string       `json:"type"`
+	URL       string       `json:"url"`
+	DateAdded string       `json:"date_added"` // microseconds since 1601.
+	Children  []chromeNode `json:"children"`
+}
+
+// chromeEpoch is the time Chrome timestamps count from.
+var chromeEpoch = time.Date(1601, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ImportChrome reads bookmarks from a Chrome (or Chromium, Edge, Brave)
+// Bookmarks file. Folders become tags, except the built in bookmarks bar,
+// other and mobile bookmarks folders.
+func ImportChrome(r io.Reader) ([]Bookmark, error) {
+	var doc struct {
+		Roots map[string]json.RawMessage `json:"roots"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	var bmarks []Bookmark
+	var walk func(n chromeNode, folders []string)
+	walk = func(n chromeNode, folders []string) {
+		if n.Type == "url" {
+			b := Bookmark{URL: n.URL, Title: n.Name, Tags: importTags(folders)}
+			if us, err := strconv.ParseInt(n.DateAdded, 10, 64); err == nil && us > 0 {
+				// a time.Duration only spans 292 years, add days and the rest.
+				const day = 24 * 60 * 60 * 1000000
+				b.Created = chromeEpoch.AddDate(0, 0, int(us/day)).
+					Add(time.Duration(us%day) * time.Microsecond)
+			}
+			bmarks = append(bmarks, b)
+			return
+		}
+		for _, c := range n.Children {
+			if c.Type == "folder" {
+				walk(c, append(folders[:len(folders):len(folders)], c.Name))
+			} else {
+				walk(c, folders)
+			}
+		}
+	}
+	for _, name := range []string{"bookmark_bar", "other", "synced"} {
+		raw, ok := doc.Roots[name]
+		if !ok {
+			continue
+		}
+		var n chromeNode
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return nil, err
+		}
+		walk(n, nil)
+	}
+	return bmarks, nil
+}
+
+var (
+	// mdHeading matches a Markdown ATX heading.
+	mdHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	// mdLink matches a Markdown inline link or autolink.
+	mdLink = regexp.MustCompile(`\[([^\]]*)\]\((\S+?)(?:\s+"[^"]*")?\)|<(https?://[^>\s]+)>`)
+	// mdHashTag matches a #tag.
+	mdHashTag = regexp.MustCompile(`(?:^|\s)#([^\s#]+)`)
+)
+
+// ImportMarkdown reads bookmarks from a Markdown list of links, for example:
+//
+//	# Go
+//	## Testing
+//	- [Go testing](https://go.dev/doc/tutorial/add-a-test) - the basics #tutorial
+//
+// Every link becomes a bookmark, the headings it is under become tags, #tags
+// on the line are added as tags and the rest of the line becomes the
+// description of lines with a single link.
+func ImportMarkdown(r io.Reader) ([]Bookmark, error) {
+	var (
+		bmarks   []Bookmark
+		headings []string
+	)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if m := mdHeading.FindStringSubmatch(line); m != nil {
+			level := len(m[1])
+			for len(headings) < level-1 {
+				headings = append(headings, "")
+			}
+			headings = append(headings[:level-1], m[2])
+			continue
+		}
+		links := mdLink.FindAllStringSubmatchIndex(line, -1)
+		if len(links) == 0 {
+			continue
+		}
+		rest := mdLink.ReplaceAllString(line, " ")
+		var tags []string
+		for _, m := range mdHashTag.FindAllStringSubmatch(rest, -1) {
+			tags = append(tags, m[1])
+		}
+		rest = mdHashTag.ReplaceAllString(rest, " ")
+		rest = strings.Trim(collapseSpace(rest), " -*+:|")
+		tags = uniqueTags(importTags(append(tags, headings...)))
+		for _, l := range links {
+			b := Bookmark{Tags: tags}
+			if l[6] >= 0 {
+				b.URL = line[l[6]:l[7]]
+			} else {
+				b.Title, b.URL = line[l[2]:l[3]], line[l[4]:l[5]]
+			}
+			if len(links) == 1 {
+				b.Desc = rest
+			}
+			bmarks = append(bmarks, b)
+		}
+	}
+	return bmarks, s.Err()
+}
+
+// ImportOptions controls how Import adds bookmarks.
+type ImportOptions struct {
+	// DryRun reports what would be added without adding anything.
+	DryRun bool
+	// Replace replaces bookmarks already in the account, otherwise they are
+	// reported as failed.
+	Replace bool
+	// Tags added to every bookmark, e.g. "imported".
+	Tags []string
+	// Minimum time between adds, default is 3 seconds as asked by Pinboard.
+	Wait time.Duration
+}
+
+// ImportReport represents the outcome of an Import.
+type ImportReport struct {
+	Added   []string         // URLs of bookmarks added, or to be added on a dry run.
+	Skipped []string         // Invalid or repeated URLs.
+	Failed  map[string]error // Bookmarks Pinboard did not add and why.
+}
+
+// validBookmarkURL reports whether URL can be bookmarked.
+func validBookmarkURL(URL string) bool {
+	u, err := url.Parse(URL)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "http", "https", "javascript", "mailto", "ftp", "file", "feed":
+		return u.Host != "" || u.Opaque != ""
+	}
+	return false
+}
+
+// Import adds bookmarks, e.g. from one of the Import functions, to the users
+// account. Bookmarks with invalid or repeated URLs are skipped. A bookmark
+// failing to add does not stop the import, every failure is reported. Adds are
+// spaced opt.Wait apart, so importing many bookmarks takes a while.
+func (p *Pinboard) Import(bmarks []Bookmark, opt ImportOptions) ImportReport {
+	report := ImportReport{Failed: make(map[string]error)}
+	seen := make(map[string]bool)
+	wait := opt.Wait
+	if wait <= 0 {
+		wait = 3 * time.Second
+	}
+	var last time.Time // time of the last add.
+	for _, b := range bmarks {
+		b.URL = strings.TrimSpace(b.URL)
+		if !validBookmarkURL(b.URL) || seen[b.URL] {
+			report.Skipped = append(report.Skipped, b.URL)
+			continue
+		}
+		seen[b.URL] = true
+		b.Tags = mergeTags(b.Tags, opt.Tags)
+		b.Replace = opt.Replace
+		if b.Title == "" {
+			// Pinboard requires a title.
+			b.Title = b.URL
+		}
+		if opt.DryRun {
+			report.Added = append(report.Added, b.URL)
+			continue
+		}
+		if !last.IsZero() {
+			p.rateLimitWait("posts/add", wait-time.Since(last))
+		}
+		last = time.Now()
+		ok, err := p.Add(b)
+		if err == nil && !ok {
+			err = errors.New("could not add bookmark: " + b.URL)
+		}
+		if err != nil {
+			report.Failed[b.URL] = err
+			continue
+		}
+		report.Added = append(report.Added, b.URL)
+	}
+	return report
+}
@@ -0,0 +1,199 @@
+package pinboard
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PageMeta represents metadata extracted from a web page.
+type PageMeta struct {
+	Title     string
+	Desc      string
+	Canonical string // Canonical URL of the page, if it declares one.
+}
+
+// Fetcher fetches web pages and extracts their metadata. The zero value is
+// ready to use.
+type Fetcher struct {
+	// Client used to fetch pages. If nil a client with Timeout is used.
+	Client *http.Client
+	// Timeout for fetching a page. Default is 10 seconds.
+	Timeout time.Duration
+	// MaxSize is the maximum number of bytes read from a page. Default is 1MB.
+	MaxSize int64
+	// UseCanonical replaces the bookmarks URL with the canonical URL of the
+	// page when enriching a bookmark.
+	UseCanonical bool
+}
+
+const (
+	defaultFetchTimeout = 10 * time.Second
+	defaultFetchMaxSize = 1 << 20
+)
+
+// client returns the HTTP client used to fetch pages.
+func (f Fetcher) client() *http.Client {
+	if f.Client != nil {
+		return f.Client
+	}
+	t := f.Timeout
+	if t == 0 {
+		t = defaultFetchTimeout
+	}
+	return &http.Client{Timeout: t}
+}
+
+// get fetches an HTML page and returns at most MaxSize bytes of it, pages which
+// are not HTML are rejected.
+func (f Fetcher) get(URL string) ([]byte, error) {
+	rsp, err := f.client().Get(URL)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	c := rsp.StatusCode
+	if c != http.StatusOK {
+		return nil, errors.New("HTTP " + strconv.Itoa(c) + " " + http.StatusText(c))
+	}
+	ct, _, err := mime.ParseMediaType(rsp.Header.Get("Content-Type"))
+	if err != nil || (ct != "text/html" && ct != "application/xhtml+xml") {
+		return nil, errors.New("not an HTML page: " + rsp.Header.Get("Content-Type"))
+	}
+	max := f.MaxSize
+	if max == 0 {
+		max = defaultFetchMaxSize
+	}
+	return ioutil.ReadAll(io.LimitReader(rsp.Body, max))
+}
+
+// Fetch fetches the page at URL and returns its metadata.
+func (f Fetcher) Fetch(URL string) (PageMeta, error) {
+	body, err := f.get(URL)
+	if err != nil {
+		return PageMeta{}, err
+	}
+	m := parsePageMeta(string(body))
+	if m.Canonical != "" {
+		// canonical links may be relative to the page.
+		base, err := url.Parse(URL)
+		ref, err2 := url.Parse(m.Canonical)
+		if err == nil && err2 == nil {
+			m.Canonical = base.ResolveReference(ref).String()
+		}
+	}
+	return m, nil
+}
+
+// parsePageMeta extracts metadata from an HTML document. OpenGraph and Twitter
+// card metadata is preferred over the <title> element and description meta
+// tag, as it tends to be free of site names and navigation.
+func parsePageMeta(doc string) PageMeta {
+	var title string
+	props := make(map[string]string)
+	var m PageMeta
+	tokens := tokenizeHTML(doc)
+	for i, t := range tokens {
+		if t.typ == htmlEndTag && t.name == "head" {
+			break
+		}
+		if t.typ != htmlStartTag {
+			continue
+		}
+		switch t.name {
+		case "title":
+			if title == "" && i+1 < len(tokens) && tokens[i+1].typ == htmlText {
+				title = collapseSpace(tokens[i+1].text)
+			}
+		case "meta":
+			k := t.attrs["property"]
+			if k == "" {
+				k = t.attrs["name"]
+			}
+			k = strings.ToLower(k)
+			if _, ok := props[k]; !ok && k != "" {
+				props[k] = collapseSpace(t.attrs["content"])
+			}
+		case "link":
+			if m.Canonical == "" && strings.EqualFold(t.attrs["rel"], "canonical") {
+				m.Canonical = strings.TrimSpace(t.attrs["href"])
+			}
+		}
+	}
+	m.Title = firstNonEmpty(props["og:title"], props["twitter:title"], title)
+	m.Desc = firstNonEmpty(props["og:description"], props["twitter:description"],
+		props["description"])
+	return m
+}
+
+// firstNonEmpty returns the first non empty string in s.
+func firstNonEmpty(s ...string) string {
+	for _, v := range s {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Enrich fills in an empty Title and Desc of the bookmark from the metadata of
+// the page at b.URL. Fields which are already set are left untouched.
+func (f Fetcher) Enrich(b Bookmark) (Bookmark, error) {
+	m, err := f.Fetch(b.URL)
+	if err != nil {
+		return b, err
+	}
+	if b.Title == "" {
+		b.Title = m.Title
+	}
+	if b.Desc == "" {
+		b.Desc = m.Desc
+	}
+	if f.UseCanonical && m.Canonical != "" {
+		b.URL = m.Canonical
+	}
+	return b, nil
+}
+
+// AddEnriched enriches the bookmark using the fetcher and adds it. If suggest
+// is true the popular tags the Pinboard service suggests for the URL are merged
+// into the bookmarks tags. A page which can't be fetched does not stop the
+// bookmark being added, unless it leaves the bookmark without a title.
+func (p *Pinboard) AddEnriched(f Fetcher, b Bookmark, suggest bool) (bool, error) {
+	e, err := f.Enrich(b)
+	if err != nil && b.Title == "" {
+		return false, err
+	}
+	if err == nil {
+		b = e
+	}
+	if suggest {
+		pop, _, err := p.Suggest(b.URL)
+		if err != nil {
+			return false, err
+		}
+		b.Tags = mergeTags(b.Tags, pop)
+	}
+	return p.Add(b)
+}
+
+// mergeTags appends the tags in more which are not already in tags, tags are
+// compared case insensitively.
+func mergeTags(tags []string, more []string) []string {
+	seen := make(map[string]bool)
+	var m []string
+	for _, t := range append(append([]string{}, tags...), more...) {
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		m = append(m, t)
+	}
+	return m
+}
@@ -0,0 +1,259 @@
+package pinboard
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Direction is the direction bookmarks are copied in by a Mirror.
+type Direction int
+
+// Directions bookmarks can be copied in.
+const (
+	// OneWay copies bookmarks from the source to the destination account.
+	OneWay Direction = iota
+	// TwoWay copies bookmarks between both accounts.
+	TwoWay
+)
+
+// ConflictPolicy decides which version of a bookmark wins when it differs
+// between the two accounts.
+type ConflictPolicy int
+
+// Policies to resolve conflicts with.
+const (
+	// Newest keeps the version from the account updated most recently.
+	// Pinboard does not expose when a single bookmark was edited, so the
+	// accounts LastUpdate is used.
+	Newest ConflictPolicy = iota
+	// SourceWins keeps the version from the source account.
+	SourceWins
+	// MergeTags keeps the version from the source account with the tags of
+	// both versions.
+	MergeTags
+)
+
+// SyncReport represents the changes made by a Mirror.
+type SyncReport struct {
+	ToDst     []string // URLs of bookmarks written to the destination account.
+	ToSrc     []string // URLs of bookmarks written to the source account.
+	Conflicts []string // URLs of bookmarks changed in both accounts.
+}
+
+// syncEntry records the fingerprints of a bookmark in both accounts after the
+// last sync.
+type syncEntry struct {
+	Src string
+	Dst string
+}
+
+// syncState is the state of a Mirror saved between runs.
+type syncState struct {
+	Bookmarks map[string]syncEntry
+}
+
+// Mirror copies bookmarks between two Pinboard accounts, for example between a
+// shared team account and a personal account. Only bookmarks tagged with all
+// of Tags are copied and creation times are kept.
+//
+// If StatePath is set the fingerprint of every synced bookmark is saved there,
+// so later runs only copy bookmarks which changed and can tell which side
+// changed. Deletions are never copied, a bookmark deleted from one account
+// after it was synced is not copied back to it.
+type Mirror struct {
+	Src       *Pinboard
+	Dst       *Pinboard
+	Tags      []string
+	Direction Direction
+	Policy    ConflictPolicy
+	StatePath string
+}
+
+// fingerprint returns a hash of the fields of a bookmark which are copied.
+func fingerprint(b Bookmark) string {
+	tags := uniqueTags(b.Tags)
+	sort.Strings(tags)
+	h := sha1.New()
+	for _, s := range []string{b.URL, b.Title, b.Desc, strings.Join(tags, " "),
+		boolToString(b.Shared), boolToString(b.ToRead)} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// byURL indexes bookmarks by URL.
+func byURL(bmarks []Bookmark) map[string]Bookmark {
+	m := make(map[string]Bookmark)
+	for _, b := range bmarks {
+		m[b.URL] = b
+	}
+	return m
+}
+
+// copyTo adds b to the account p, replacing any existing bookmark.
+func copyTo(p *Pinboard, b Bookmark) error {
+	b.Replace = true
+	ok, err := p.Add(b)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("could not copy bookmark: " + b.URL)
+	}
+	return nil
+}
+
+// Sync copies new and changed bookmarks between the accounts and returns what
+// was copied. State is saved after every copied bookmark, so an interrupted
+// sync picks up where it stopped.
+func (m *Mirror) Sync() (SyncReport, error) {
+	var report SyncReport
+	state := syncState{Bookmarks: make(map[string]syncEntry)}
+	if m.StatePath != "" {
+		if err := loadJSON(m.StatePath, &state); err != nil {
+			return report, err
+		}
+		if state.Bookmarks == nil {
+			state.Bookmarks = make(map[string]syncEntry)
+		}
+	}
+	save := func() error {
+		if m.StatePath == "" {
+			return nil
+		}
+		return saveJSON(m.StatePath, state)
+	}
+
+	srcAll, err := m.Src.Bookmarks(m.Tags, 0, 0, time.Time{}, time.Time{}, false)
+	if err != nil {
+		return report, err
+	}
+	dstAll, err := m.Dst.Bookmarks(m.Tags, 0, 0, time.Time{}, time.Time{}, false)
+	if err != nil {
+		return report, err
+	}
+	src, dst := byURL(srcAll), byURL(dstAll)
+
+	var urls []string
+	for u := range src {
+		urls = append(urls, u)
+	}
+	for u := range dst {
+		if _, ok := src[u]; !ok {
+			urls = append(urls, u)
+		}
+	}
+	sort.Strings(urls)
+
+	// which account was updated last is only looked up on the first conflict.
+	var srcNewer *bool
+	newest := func() (bool, error) {
+		if srcNewer == nil {
+			s, err := m.Src.LastUpdate()
+			if err != nil {
+				return false, err
+			}
+			d, err := m.Dst.LastUpdate()
+			if err != nil {
+				return false, err
+			}
+			n := !s.Before(d)
+			srcNewer = &n
+		}
+		return *srcNewer, nil
+	}
+
+	for _, u := range urls {
+		s, inSrc := src[u]
+		d, inDst := dst[u]
+		last, synced := state.Bookmarks[u]
+
+		var toDst, toSrc *Bookmark
+		switch {
+		case inSrc && !inDst:
+			if !synced {
+				toDst = &s
+			}
+		case !inSrc && inDst:
+			if !synced && m.Direction == TwoWay {
+				toSrc = &d
+			}
+		default:
+			fs, fd := fingerprint(s), fingerprint(d)
+			if fs == fd {
+				break
+			}
+			srcChanged := !synced || fs != last.Src
+			dstChanged := !synced || fd != last.Dst
+			switch {
+			case srcChanged && !dstChanged:
+				toDst = &s
+			case dstChanged && !srcChanged:
+				if m.Direction == TwoWay {
+					toSrc = &d
+				}
+			case srcChanged && dstChanged:
+				report.Conflicts = append(report.Conflicts, u)
+				w, err := m.resolve(s, d, newest)
+				if err != nil {
+					return report, err
+				}
+				if fingerprint(w) != fs && m.Direction == TwoWay {
+					toSrc = &w
+				}
+				if fingerprint(w) != fd {
+					toDst = &w
+				}
+			}
+		}
+
+		if toDst != nil {
+			if err := copyTo(m.Dst, *toDst); err != nil {
+				return report, err
+			}
+			report.ToDst = append(report.ToDst, u)
+			d, inDst = *toDst, true
+		}
+		if toSrc != nil {
+			if err := copyTo(m.Src, *toSrc); err != nil {
+				return report, err
+			}
+			report.ToSrc = append(report.ToSrc, u)
+			s, inSrc = *toSrc, true
+		}
+		if inSrc && inDst {
+			state.Bookmarks[u] = syncEntry{Src: fingerprint(s), Dst: fingerprint(d)}
+		}
+		if toDst != nil || toSrc != nil {
+			if err := save(); err != nil {
+				return report, err
+			}
+		}
+	}
+	return report, save()
+}
+
+// resolve returns the version of a bookmark which wins a conflict between the
+// source version s and the destination version d.
+func (m *Mirror) resolve(s, d Bookmark, newest func() (bool, error)) (Bookmark, error) {
+	switch m.Policy {
+	case SourceWins:
+		return s, nil
+	case MergeTags:
+		s.Tags = mergeTags(s.Tags, d.Tags)
+		return s, nil
+	}
+	srcNewer, err := newest()
+	if err != nil {
+		return Bookmark{}, err
+	}
+	if srcNewer {
+		return s, nil
+	}
+	return d, nil
+}
@@ -0,0 +1,144 @@
+package pinboard
+
+import (
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters which only identify where a link was
+// shared from and never change the page itself. Any parameter starting with
+// "utm_" is also treated as a tracking parameter.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"igshid":  true,
+	"yclid":   true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+	"ref_src": true,
+}
+
+// isTrackingParam returns true if the query parameter k is a tracking
+// parameter.
+func isTrackingParam(k string) bool {
+	k = strings.ToLower(k)
+	return strings.HasPrefix(k, "utm_") || trackingParams[k]
+}
+
+// stripTrackingParams removes tracking parameters from a raw query string,
+// the order and encoding of the remaining parameters is left untouched.
+func stripTrackingParams(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	var keep []string
+	for _, kv := range strings.Split(rawQuery, "&") {
+		k := kv
+		if i := strings.Index(kv, "="); i >= 0 {
+			k = kv[:i]
+		}
+		if u, err := url.QueryUnescape(k); err == nil {
+			k = u
+		}
+		if isTrackingParam(k) {
+			continue
+		}
+		keep = append(keep, kv)
+	}
+	return strings.Join(keep, "&")
+}
+
+// URLPolicy specifies how URLs are normalized before they are sent to the
+// Pinboard service. Pinboard matches bookmarks on the exact URL, normalizing
+// URLs the same way on write and on lookup avoids bookmarks which differ only
+// by e.g. a trailing slash. The zero value leaves URLs untouched.
+type URLPolicy struct {
+	// Lower case the scheme and host.
+	LowerCase bool
+	// Remove port 80 from http and port 443 from https URLs.
+	DefaultPort bool
+	// Remove trailing slashes from the path, the root path "/" is kept.
+	TrailingSlash bool
+	// Remove the fragment.
+	Fragment bool
+	// Remove tracking query parameters such as utm_source or fbclid.
+	Tracking bool
+}
+
+// DefaultURLPolicy enables every normalization.
+var DefaultURLPolicy = URLPolicy{
+	LowerCase:     true,
+	DefaultPort:   true,
+	TrailingSlash: true,
+	Fragment:      true,
+	Tracking:      true,
+}
+
+// Normalize returns rawurl normalized according to the policy. Unless the
+// policy is the zero value an empty path is normalized to "/", as the two are
+// equivalent.
+func (u URLPolicy) Normalize(rawurl string) (string, error) {
+	v, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+	if u == (URLPolicy{}) {
+		return rawurl, nil
+	}
+	if u.LowerCase {
+		v.Scheme = strings.ToLower(v.Scheme)
+		v.Host = strings.ToLower(v.Host)
+	}
+	if u.DefaultPort {
+		port := v.Port()
+		s := strings.ToLower(v.Scheme)
+		if (s == "http" && port == "80") || (s == "https" && port == "443") {
+			v.Host = strings.TrimSuffix(v.Host, ":"+port)
+		}
+	}
+	if v.Host != "" && v.Path == "" {
+		v.Path = "/"
+	}
+	if u.TrailingSlash && len(v.Path) > 1 {
+		v.Path = strings.TrimRight(v.Path, "/")
+		v.RawPath = strings.TrimRight(v.RawPath, "/")
+		if v.Path == "" {
+			v.Path = "/"
+			v.RawPath = ""
+		}
+	}
+	if u.Fragment {
+		v.Fragment = ""
+		v.RawFragment = ""
+	}
+	if u.Tracking {
+		v.RawQuery = stripTrackingParams(v.RawQuery)
+		if v.RawQuery == "" {
+			v.ForceQuery = false
+		}
+	}
+	return v.String(), nil
+}
+
+// SetURLPolicy sets the policy used to normalize URLs passed to Add, Del and
+// Get. A nil policy, the default, sends URLs verbatim.
+func (p *Pinboard) SetURLPolicy(u *URLPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.policy = u
+}
+
+// normalizeURL normalizes URL according to the clients URL policy.
+func (p *Pinboard) normalizeURL(URL string) (string, error) {
+	p.mu.RLock()
+	policy := p.policy
+	p.mu.RUnlock()
+	if policy == nil {
+		return URL, nil
+	}
+	return policy.Normalize(URL)
+}
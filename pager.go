@@ -0,0 +1,124 @@
+package pinboard
+
+import (
+	"io"
+	"time"
+)
+
+// Pager fetches the users bookmarks from posts/all a page at a time, so
+// callers don't have to write their own paging loop:
+//
+//	pg := &pinboard.Pager{Pinboard: pin, PageSize: 100}
+//	for {
+//	    page, err := pg.Next()
+//	    if err == io.EOF {
+//	        break
+//	    }
+//	    ...
+//	}
+//
+// Pinboard returns bookmarks newest first, bookmarks added while paging shift
+// later pages so a bookmark may be returned twice.
+type Pager struct {
+	Pinboard *Pinboard
+	// Filters, as for Bookmarks.
+	Tags  []string
+	Start time.Time
+	End   time.Time
+	Meta  bool
+	// Number of bookmarks in each page, default is 100.
+	PageSize int
+	// Minimum time between page requests, default is 5 minutes as Pinboard
+	// asks posts/all be requested at most once every five minutes. Setting a
+	// shorter wait risks HTTP 429 responses.
+	Wait time.Duration
+	// Offset of the next page. Set it, or set StatePath, to resume paging.
+	Offset int
+	// StatePath is a file the offset is saved to once a page is done with,
+	// which is when Next is called for the page after it. If the file exists
+	// paging resumes from the offset in it.
+	StatePath string
+
+	started bool
+	done    bool
+	pending int       // size of the page returned last.
+	last    time.Time // time of the last request.
+}
+
+// pagerState is the state of a Pager saved between runs.
+type pagerState struct {
+	Offset int
+}
+
+// Next returns the next page of bookmarks, or io.EOF once all bookmarks have
+// been returned.
+func (pg *Pager) Next() ([]Bookmark, error) {
+	if !pg.started {
+		pg.started = true
+		if pg.StatePath != "" {
+			s := pagerState{Offset: pg.Offset}
+			if err := loadJSON(pg.StatePath, &s); err != nil {
+				return nil, err
+			}
+			pg.Offset = s.Offset
+		}
+	}
+	if pg.pending > 0 {
+		pg.Offset += pg.pending
+		pg.pending = 0
+		if err := pg.save(); err != nil {
+			return nil, err
+		}
+	}
+	if pg.done {
+		return nil, io.EOF
+	}
+
+	size := pg.PageSize
+	if size <= 0 {
+		size = 100
+	}
+	wait := pg.Wait
+	if wait <= 0 {
+		wait = 5 * time.Minute
+	}
+	if !pg.last.IsZero() {
+		pg.Pinboard.rateLimitWait("posts/all", wait-time.Since(pg.last))
+	}
+	pg.last = time.Now()
+	page, err := pg.Pinboard.Bookmarks(pg.Tags, pg.Offset, size, pg.Start, pg.End, pg.Meta)
+	if err != nil {
+		return nil, err
+	}
+	if len(page) < size {
+		pg.done = true
+	}
+	if len(page) == 0 {
+		return nil, io.EOF
+	}
+	pg.pending = len(page)
+	return page, nil
+}
+
+// save writes the offset to StatePath.
+func (pg *Pager) save() error {
+	if pg.StatePath == "" {
+		return nil
+	}
+	return saveJSON(pg.StatePath, pagerState{Offset: pg.Offset})
+}
+
+// All returns all remaining bookmarks.
+func (pg *Pager) All() ([]Bookmark, error) {
+	var all []Bookmark
+	for {
+		page, err := pg.Next()
+		if err == io.EOF {
+			return all, nil
+		}
+		if err != nil {
+			return all, err
+		}
+		all = append(all, page...)
+	}
+}
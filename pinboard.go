@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,10 +20,55 @@ import (
 // to point to a mock server. ** Do not ** change this URI.
 var BaseURL = "https://api.pinboard.in/v1/%s/?%s"
 
-// Pinboard a single instance to interact with bookmarks and other data
+// ErrNotFound is returned when a bookmark does not exist.
+var ErrNotFound = errors.New("bookmark not found")
+
+// ErrConflict is returned by UpdateIf when a bookmark was changed by someone
+// else since the caller fetched it.
+var ErrConflict = errors.New("bookmark was modified concurrently")
+
+// StatusError is returned when the Pinboard service responds with a HTTP status
+// other than 200 OK.
+type StatusError struct {
+	Code int // HTTP status code.
+}
+
+func (e *StatusError) Error() string {
+	return "HTTP " + strconv.Itoa(e.Code) + " " + http.StatusText(e.Code)
+}
+
+// Pinboard a single instance to interact with bookmarks and other data. It is
+// safe for concurrent use by multiple goroutines, including calling Auth to
+// change the token while other requests are in flight. Requests already
+// started carry on with the token they started with.
 type Pinboard struct {
+	mu sync.RWMutex // Guards the fields below.
+
 	token  string // e.g. username:TOKEN
 	authed bool   // Authenticated with Pinboard service?
+
+	policy *URLPolicy // Normalizes URLs passed to Add, Del and Get.
+	hooks  Hooks      // Observe requests, nil if none.
+	client *http.Client
+
+	postMode     PostMode
+	postRejected bool // Server refused a POST, send write requests as GETs.
+
+	onAuthExpired func(expired string) (string, error)
+	refresh       sync.Mutex // Held while asking onAuthExpired for a token.
+}
+
+// User represents a Pinboard user
+type User struct {
+	Name   string
+	Token  string // e.g. username:TOKEN
+	Secret string // RSS key for the private feeds of the user.
+}
+
+// Feed returns the query selecting the private feed of the user, of bookmarks
+// with tags if given.
+func (u User) Feed(tags ...string) FeedQuery {
+	return FeedQuery{User: u.Name, Secret: u.Secret, Tags: tags}
 }
 
 // Bookmark represents a Pinboard bookmark
@@ -152,18 +199,50 @@ func atoi(s string) int {
 	return l
 }
 
+// doStream performs a HTTP GET on a URL and returns the response body, which
+// the caller must close.
+func doStream(url string) (io.ReadCloser, error) {
+	r, _, err := doStatus(http.DefaultClient, url)
+	return r, err
+}
+
+// doStatus is doStream with client c, which also returns the status code of
+// the response, 0 if there was none.
+func doStatus(c *http.Client, url string) (io.ReadCloser, int, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	return doRequest(c, req)
+}
+
+// doRequest is doStatus for any request.
+func doRequest(c *http.Client, req *http.Request) (io.ReadCloser, int, error) {
+	rsp, err := c.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	code := rsp.StatusCode
+	if code != http.StatusOK {
+		rsp.Body.Close()
+		return nil, code, &StatusError{Code: code}
+	}
+	return rsp.Body, code, nil
+}
+
 // do performs a HTTP GET on a URL.
 func do(url string) (data []byte, err error) {
-	rsp, err := http.Get(url)
+	r, err := doStream(url)
 	if err != nil {
 		return nil, err
 	}
-	c := rsp.StatusCode
-	if c != http.StatusOK {
-		return nil, errors.New("HTTP " + strconv.Itoa(c) + " " + http.StatusText(c))
-	}
-	defer rsp.Body.Close()
-	body, err := ioutil.ReadAll(rsp.Body)
+	return readBody(r)
+}
+
+// readBody reads and closes a response body.
+func readBody(r io.ReadCloser) ([]byte, error) {
+	defer r.Close()
+	body, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
@@ -179,19 +258,42 @@ func New() *Pinboard {
 	return &Pinboard{}
 }
 
+// NewWithToken returns a new instance of Pinboard which trusts token, in the
+// format username:TOKEN, without checking it with Auth. The first request
+// proves the token, if Pinboard rejects it an *AuthError is returned and the
+// client is no longer authenticated. This saves the user/api_token request
+// Auth makes.
+func NewWithToken(token string) *Pinboard {
+	return &Pinboard{token: token, authed: true}
+}
+
+// SetHTTPClient sets the HTTP client requests are made with, e.g. to set a
+// timeout or a Recorder as its transport. A nil client, the default, uses
+// http.DefaultClient.
+func (p *Pinboard) SetHTTPClient(c *http.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.client = c
+}
+
 // Token returns the users token in the format username:TOKEN.
-func (p Pinboard) Token() string {
+func (p *Pinboard) Token() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.token
 }
 
-// IsAuthed checks if the user has been authenticated with the Pinboard service
-func (p Pinboard) IsAuthed() bool {
+// IsAuthed checks if the user has been authenticated with the Pinboard service,
+// a client from NewWithToken is until Pinboard rejects its token.
+func (p *Pinboard) IsAuthed() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.authed
 }
 
 // makeURL constructs a valid URL which can be used to make a request to the
 // Pinboard service.
-func (p Pinboard) makeURL(method string, vals url.Values) string {
+func (p *Pinboard) makeURL(method string, vals url.Values) string {
 	if vals == nil {
 		vals = url.Values{}
 	}
@@ -199,26 +301,73 @@ func (p Pinboard) makeURL(method string, vals url.Values) string {
 
 	a := vals.Get("auth_token")
 	if a == "" {
-		vals.Set("auth_token", p.token)
+		vals.Set("auth_token", p.Token())
 	}
 
 	return fmt.Sprintf(BaseURL, method, vals.Encode())
 }
 
+// request performs a request to the Pinboard service. If it fails with HTTP
+// 401 the client is marked unauthenticated and, if a fresh token is to be had
+// from OnAuthExpired, the request is retried once with it. The caller must
+// close the response body.
+func (p *Pinboard) request(method string, vals url.Values) (io.ReadCloser, error) {
+	if vals == nil {
+		vals = url.Values{}
+	}
+	// requests passing their own token, i.e. from Auth(...), are never retried.
+	explicit := vals.Get("auth_token") != ""
+	u := p.makeURL(method, vals)
+	p.mu.RLock()
+	hooks, client := p.hooks, p.client
+	p.mu.RUnlock()
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r, err := p.exchange(client, hooks, method, u)
+	if !isUnauthorized(err) {
+		return r, err
+	}
+	token := vals.Get("auth_token")
+	if explicit || !p.authExpired(token) {
+		return nil, &AuthError{User: tokenUser(token), Err: err}
+	}
+	if hooks != nil {
+		hooks.Retry(RequestInfo{Method: method, URL: redactURL(u), Status: http.StatusUnauthorized,
+			Err: err}, 1)
+	}
+	vals.Del("auth_token")
+	u = p.makeURL(method, vals)
+	r, err = p.exchange(client, hooks, method, u)
+	if isUnauthorized(err) {
+		p.markExpired(vals.Get("auth_token"))
+		return nil, &AuthError{User: tokenUser(vals.Get("auth_token")), Err: err}
+	}
+	return r, err
+}
+
 // performRequest performs a request to the Pinboard service.
-func (p Pinboard) performRequest(method string, vals url.Values) ([]byte, error) {
+func (p *Pinboard) performRequest(method string, vals url.Values) ([]byte, error) {
 	// Only calls from Auth(...) can pass when p.auth == false, as we are
 	// requesting authentication. For all other calls the API must already be
 	// authorized by a previous call to Auth(...).
-	if !p.authed && method != "user/api_token" {
-		return nil, errors.New("API not authorized")
+	if !p.IsAuthed() && method != "user/api_token" && !p.reauth() {
+		return nil, &AuthError{User: p.user(), Err: ErrNotAuthorized}
 	}
-	url := p.makeURL(method, vals)
-	data, err := do(url)
+	r, err := p.request(method, vals)
 	if err != nil {
 		return nil, err
 	}
-	return data, nil
+	return readBody(r)
+}
+
+// performRequestStream performs a request to the Pinboard service and returns
+// the response body unread, which the caller must close.
+func (p *Pinboard) performRequestStream(method string, vals url.Values) (io.ReadCloser, error) {
+	if !p.IsAuthed() && !p.reauth() {
+		return nil, &AuthError{User: p.user(), Err: ErrNotAuthorized}
+	}
+	return p.request(method, vals)
 }
 
 // USER
@@ -236,17 +385,44 @@ func (p *Pinboard) Auth(token string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	p.mu.Lock()
 	p.token = token
 	p.authed = true
+	p.mu.Unlock()
+	return j["result"], nil
+}
+
+// Secret returns the users secret RSS key, which is needed to read their
+// private feeds.
+func (p *Pinboard) Secret() (string, error) {
+	data, err := p.performRequest("user/secret", nil)
+	if err != nil {
+		return "", err
+	}
+	j, err := decodeJSON(data)
+	if err != nil {
+		return "", err
+	}
 	return j["result"], nil
 }
 
+// User returns the user the client is authenticated as, with their secret RSS
+// key.
+func (p *Pinboard) User() (User, error) {
+	token := p.Token()
+	secret, err := p.Secret()
+	if err != nil {
+		return User{}, err
+	}
+	return User{Name: tokenUser(token), Token: token, Secret: secret}, nil
+}
+
 // POSTS
 
 // LastUpdate returns the last time a bookmark was added, updated or deleted.
 // Use this before calling Bookmarks() to see if the data has changed since the
 // last fetch.
-func (p Pinboard) LastUpdate() (time.Time, error) {
+func (p *Pinboard) LastUpdate() (time.Time, error) {
 	data, err := p.performRequest("posts/update", nil)
 	if err != nil {
 		return time.Time{}, err
@@ -264,9 +440,13 @@ func (p Pinboard) LastUpdate() (time.Time, error) {
 
 // Add adds a bookmark.
 func (p *Pinboard) Add(b Bookmark) (bool, error) {
+	URL, err := p.normalizeURL(b.URL)
+	if err != nil {
+		return false, err
+	}
 	v := url.Values{}
 	// mandatory.
-	v.Set("url", b.URL)
+	v.Set("url", URL)
 	v.Set("description", b.Title)
 	// optional
 	if b.Desc != "" {
@@ -306,8 +486,79 @@ func (p *Pinboard) Add(b Bookmark) (bool, error) {
 	return true, nil
 }
 
+// Update changes a single bookmark: the bookmark for URL is fetched, passed to
+// fn and written back. Every field fn leaves alone is preserved, including the
+// creation time. If fn changes the URL the bookmark is moved to the new URL.
+// Update is last writer wins, a change made elsewhere between the fetch and
+// the write is lost. Use UpdateIf to detect such changes.
+func (p *Pinboard) Update(URL string, fn func(*Bookmark)) error {
+	return p.update(URL, nil, fn)
+}
+
+// UpdateIf is Update which only writes the bookmark if its change detection
+// signature is still meta, the Meta of the bookmark when the caller fetched
+// it, otherwise ErrConflict is returned and nothing is written. Pinboard has
+// no conditional writes so this narrows, but can't close, the window for lost
+// updates.
+func (p *Pinboard) UpdateIf(URL string, meta []byte, fn func(*Bookmark)) error {
+	if meta == nil {
+		meta = []byte{}
+	}
+	return p.update(URL, meta, fn)
+}
+
+// update changes the bookmark for URL with fn, if meta is not nil only if its
+// change detection signature is meta.
+func (p *Pinboard) update(URL string, meta []byte, fn func(*Bookmark)) error {
+	orig, err := p.bookmark(URL)
+	if err != nil {
+		return err
+	}
+	if meta != nil && string(orig.Meta) != string(meta) {
+		return ErrConflict
+	}
+	b := orig
+	b.Tags = append([]string(nil), orig.Tags...)
+	fn(&b)
+	if b.Created.IsZero() {
+		b.Created = orig.Created
+	}
+	b.Replace = true
+
+	ok, err := p.Add(b)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("could not update bookmark: " + URL)
+	}
+	from, err := p.normalizeURL(orig.URL)
+	if err != nil {
+		return err
+	}
+	to, err := p.normalizeURL(b.URL)
+	if err != nil {
+		return err
+	}
+	if from != to {
+		if _, err := p.Del(orig.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Del deletes a bookmark.
-func (p Pinboard) Del(URL string) (bool, error) {
+func (p *Pinboard) Del(URL string) (bool, error) {
+	URL, err := p.normalizeURL(URL)
+	if err != nil {
+		return false, err
+	}
+	return p.del(URL)
+}
+
+// del deletes the bookmark for exactly URL, ignoring the URL policy.
+func (p *Pinboard) del(URL string) (bool, error) {
 	v := url.Values{}
 	v.Set("url", URL)
 	data, err := p.performRequest("posts/delete", v)
@@ -328,12 +579,16 @@ func (p Pinboard) Del(URL string) (bool, error) {
 // date or URL is given, date of most recent bookmark will be used. The meta
 // flag allows the ability to include a change detection signature for each
 // bookmark.
-func (p Pinboard) Get(dt time.Time, URL string, tags []string, meta bool) ([]Bookmark, error) {
+func (p *Pinboard) Get(dt time.Time, URL string, tags []string, meta bool) ([]Bookmark, error) {
 	v := url.Values{}
 	if !dt.IsZero() {
 		v.Set("dt", dt.UTC().Format(time.RFC3339))
 	}
 	if URL != "" {
+		URL, err := p.normalizeURL(URL)
+		if err != nil {
+			return nil, err
+		}
 		v.Set("url", URL)
 	}
 	if tags != nil {
@@ -381,8 +636,23 @@ func (p Pinboard) Get(dt time.Time, URL string, tags []string, meta bool) ([]Boo
 	return bmarks, nil
 }
 
-// Dates returns a list of dates with the number of posts at each date.
-func (p Pinboard) Dates(tags []string) ([]Post, error) {
+// bookmark returns the bookmark for URL, including its change detection
+// signature. Returns ErrNotFound if there is no bookmark for URL.
+func (p *Pinboard) bookmark(URL string) (Bookmark, error) {
+	bmarks, err := p.Get(time.Time{}, URL, nil, true)
+	if err != nil {
+		return Bookmark{}, err
+	}
+	if len(bmarks) == 0 {
+		return Bookmark{}, ErrNotFound
+	}
+	return bmarks[0], nil
+}
+
+// Dates returns a list of dates with the number of posts at each date. Dates
+// are sorted newest first, or in the order sort if given. sort is an optional
+// single value, Dates(tags) and Dates(tags, s) are the only two forms.
+func (p *Pinboard) Dates(tags []string, sort ...Sort) (PostList, error) {
 	v := url.Values{}
 	if tags != nil {
 		var g string
@@ -400,7 +670,7 @@ func (p Pinboard) Dates(tags []string) ([]Post, error) {
 		return nil, err
 	}
 	d := j["dates"].(map[string]interface{})
-	var posts []Post
+	var posts PostList
 	for k, v := range d {
 		w, err := time.Parse("2006-01-02", k)
 		if err != nil {
@@ -408,12 +678,15 @@ func (p Pinboard) Dates(tags []string) ([]Post, error) {
 		}
 		posts = append(posts, Post{Date: w, Count: atoi(v.(string))})
 	}
-	return posts, nil
+	if len(sort) == 0 {
+		sort = []Sort{{By: ByDate, Desc: true}}
+	}
+	return posts.Sort(sort[0]), nil
 }
 
 // Recent returns a list of the user's most recent posts, filtered by tag. count
 // indicates the number results to return, default is 15 max is 100.
-func (p Pinboard) Recent(tags []string, count int) ([]Bookmark, error) {
+func (p *Pinboard) Recent(tags []string, count int) ([]Bookmark, error) {
 	v := url.Values{}
 	if tags != nil {
 		var g string
@@ -464,9 +737,58 @@ func (p Pinboard) Recent(tags []string, count int) ([]Bookmark, error) {
 // - 'start' Return only bookmarks created after this time.
 // - 'end' Return only bookmarks created before this time.
 // - 'meta' A meta flag to include a change detection signature for each bookmark.
-func (p Pinboard) Bookmarks(tags []string, offset int, count int,
+func (p *Pinboard) Bookmarks(tags []string, offset int, count int,
 	start time.Time, end time.Time, meta bool) ([]Bookmark, error) {
 
+	v := bookmarksQuery(tags, start, end, meta)
+	v.Set("start", strconv.Itoa(offset))
+	v.Set("results", strconv.Itoa(count))
+	data, err := p.performRequest("posts/all", v)
+	if err != nil {
+		return nil, err
+	}
+	j, err := decodeJSONListIFace(data)
+	if err != nil {
+		return nil, err
+	}
+	var bmarks []Bookmark
+	for i := 0; i < len(j); i++ {
+		bmarks = append(bmarks, bookmarkFromJSON(j[i].(map[string]interface{})))
+	}
+	return bmarks, nil
+}
+
+// StreamBookmarks calls fn with each bookmark in the users account as it is
+// decoded, so all bookmarks are never held in memory at once. Takes the same
+// filters as Bookmarks. If fn returns an error streaming stops and the error
+// is returned.
+func (p *Pinboard) StreamBookmarks(tags []string, start time.Time, end time.Time,
+	meta bool, fn func(Bookmark) error) error {
+
+	r, err := p.performRequestStream("posts/all", bookmarksQuery(tags, start, end, meta))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	dec := json.NewDecoder(r)
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		var m map[string]interface{}
+		if err := dec.Decode(&m); err != nil {
+			return err
+		}
+		if err := fn(bookmarkFromJSON(m)); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// bookmarksQuery returns the query of a posts/all request.
+func bookmarksQuery(tags []string, start time.Time, end time.Time, meta bool) url.Values {
 	v := url.Values{}
 	if tags != nil {
 		var g string
@@ -475,9 +797,6 @@ func (p Pinboard) Bookmarks(tags []string, offset int, count int,
 		}
 		v.Set("tag", strings.Trim(g, ","))
 	}
-	v.Set("start", strconv.Itoa(offset))
-	v.Set("results", strconv.Itoa(count))
-
 	if !start.IsZero() {
 		v.Set("fromdt", start.UTC().Format(time.RFC3339))
 	}
@@ -489,42 +808,32 @@ func (p Pinboard) Bookmarks(tags []string, offset int, count int,
 	} else {
 		v.Set("meta", "no")
 	}
-	data, err := p.performRequest("posts/all", v)
+	return v
+}
+
+// bookmarkFromJSON converts a bookmark decoded from a posts/all response.
+func bookmarkFromJSON(p map[string]interface{}) Bookmark {
+	t, err := time.Parse(time.RFC3339, p["time"].(string))
 	if err != nil {
-		return nil, err
+		t = time.Time{}
 	}
-	j, err := decodeJSONListIFace(data)
-	if err != nil {
-		return nil, err
+	return Bookmark{
+		URL:     p["href"].(string),
+		Title:   p["description"].(string),
+		Desc:    p["extended"].(string),
+		Tags:    strings.Split(p["tags"].(string), " "),
+		Created: t,
+		Shared:  stringToBool(p["shared"].(string)),
+		ToRead:  stringToBool(p["toread"].(string)),
+		Hash:    []byte(p["hash"].(string)),
+		Meta:    []byte(p["meta"].(string)),
 	}
-	var bmarks []Bookmark
-	for i := 0; i < len(j); i++ {
-		p := j[i].(map[string]interface{})
-
-		t, err := time.Parse(time.RFC3339, p["time"].(string))
-		if err != nil {
-			t = time.Time{}
-		}
-		b := Bookmark{
-			URL:     p["href"].(string),
-			Title:   p["description"].(string),
-			Desc:    p["extended"].(string),
-			Tags:    strings.Split(p["tags"].(string), " "),
-			Created: t,
-			Shared:  stringToBool(p["shared"].(string)),
-			ToRead:  stringToBool(p["toread"].(string)),
-			Hash:    []byte(p["hash"].(string)),
-			Meta:    []byte(p["meta"].(string)),
-		}
-		bmarks = append(bmarks, b)
-	}
-	return bmarks, nil
 }
 
 // Suggest returns a list of popular tags and recommended tags for a given URL.
 // Popular tags are tags used site-wide for the url; recommended tags are drawn
 // from the user's own tags.
-func (p Pinboard) Suggest(URL string) (Popular, Recommended, error) {
+func (p *Pinboard) Suggest(URL string) (Popular, Recommended, error) {
 	v := url.Values{}
 	v.Set("url", URL)
 	data, err := p.performRequest("posts/suggest", v)
@@ -555,8 +864,9 @@ func (p Pinboard) Suggest(URL string) (Popular, Recommended, error) {
 // TAGS
 
 // Tags returns a full list of the user's tags along with the number of times
-// they were used.
-func (p Pinboard) Tags() ([]Tag, error) {
+// they were used. Tags are sorted by name, or in the order sort if given. sort
+// is an optional single value, Tags() and Tags(s) are the only two forms.
+func (p *Pinboard) Tags(sort ...Sort) (TagList, error) {
 	data, err := p.performRequest("tags/get", nil)
 	if err != nil {
 		return nil, err
@@ -565,16 +875,19 @@ func (p Pinboard) Tags() ([]Tag, error) {
 	if err != nil {
 		return nil, err
 	}
-	var tags []Tag
+	var tags TagList
 	for k, v := range j {
 		tags = append(tags, Tag{Name: k, Count: atoi(v)})
 	}
-	return tags, nil
+	if len(sort) == 0 {
+		sort = []Sort{{By: ByName}}
+	}
+	return tags.Sort(sort[0]), nil
 }
 
 // DelTag delete an existing tag, returns true if the delete operation
 // succeeds.
-func (p Pinboard) DelTag(tag string) (bool, error) {
+func (p *Pinboard) DelTag(tag string) (bool, error) {
 	v := url.Values{}
 	v.Set("tag", tag)
 	data, err := p.performRequest("tags/delete", v)
@@ -593,7 +906,7 @@ func (p Pinboard) DelTag(tag string) (bool, error) {
 
 // RenTag rename a tag, or fold it in to an existing tag. Match is not case
 // sensitive, returns true if the rename operation succeeds.
-func (p Pinboard) RenTag(oldTag, newTag string) (bool, error) {
+func (p *Pinboard) RenTag(oldTag, newTag string) (bool, error) {
 	v := url.Values{}
 	v.Set("old", oldTag)
 	v.Set("new", newTag)
@@ -614,7 +927,7 @@ func (p Pinboard) RenTag(oldTag, newTag string) (bool, error) {
 // NOTES
 
 // Notes returns a list of the user's notes
-func (p Pinboard) Notes() ([]NoteMetadata, error) {
+func (p *Pinboard) Notes() ([]NoteMetadata, error) {
 	data, err := p.performRequest("notes/list", nil)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,191 @@
+package pinboard_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+// graphBookmarks bookmarks used to build a tag graph.
+var graphBookmarks = []pinboard.Bookmark{
+	{URL: "https://a.com", Tags: []string{"go", "web"},
+		Created: time.Date(2015, 7, 1, 10, 0, 0, 0, time.UTC)},
+	{URL: "https://b.com", Tags: []string{"web", "go", "go"},
+		Created: time.Date(2015, 7, 1, 12, 0, 0, 0, time.UTC)},
+	{URL: "https://c.com", Tags: []string{"css", "web"},
+		Created: time.Date(2015, 7, 3, 9, 0, 0, 0, time.UTC)},
+}
+
+func TestNewTagGraph(t *testing.T) {
+	got := pinboard.NewTagGraph(graphBookmarks)
+
+	wantNodes := []pinboard.Tag{{Name: "css", Count: 1}, {Name: "go", Count: 2},
+		{Name: "web", Count: 3}}
+	wantEdges := []pinboard.TagEdge{{From: "css", To: "web", Weight: 1},
+		{From: "go", To: "web", Weight: 2}}
+
+	if len(got.Nodes) != len(wantNodes) {
+		t.Fatalf("nodes: got %v want %v", got.Nodes, wantNodes)
+	}
+	for i := range wantNodes {
+		if got.Nodes[i] != wantNodes[i] {
+			t.Errorf("nodes: got %v want %v", got.Nodes, wantNodes)
+		}
+	}
+	if len(got.Edges) != len(wantEdges) {
+		t.Fatalf("edges: got %v want %v", got.Edges, wantEdges)
+	}
+	for i := range wantEdges {
+		if got.Edges[i] != wantEdges[i] {
+			t.Errorf("edges: got %v want %v", got.Edges, wantEdges)
+		}
+	}
+
+	n := got.Neighbours("web")
+	if len(n) != 2 || n[0].To != "go" || n[1].To != "css" {
+		t.Errorf("neighbours: got %v want [go css]", n)
+	}
+}
+
+func TestTagGraphWriteDOT(t *testing.T) {
+	g := pinboard.NewTagGraph([]pinboard.Bookmark{
+		{Tags: []string{`say "hi"`, "go"}},
+	})
+
+	var buf bytes.Buffer
+	err := g.WriteDOT(&buf)
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	want := `graph tags {
+  "go" [count=1];
+  "say \"hi\"" [count=1];
+  "go" -- "say \"hi\"" [weight=1];
+}
+`
+	if buf.String() != want {
+		t.Errorf("dot: got %s want %s", buf.String(), want)
+	}
+}
+
+func TestTagGraphWriteGraphML(t *testing.T) {
+	g := pinboard.NewTagGraph(graphBookmarks)
+
+	var buf bytes.Buffer
+	err := g.WriteGraphML(&buf)
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	var doc struct {
+		Nodes []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"graph>node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+			Target string `xml:"target,attr"`
+			Weight string `xml:"data"`
+		} `xml:"graph>edge"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if len(doc.Nodes) != 3 {
+		t.Errorf("nodes: got %d want 3", len(doc.Nodes))
+	}
+	if len(doc.Edges) != 2 || doc.Edges[1].Source != "go" ||
+		doc.Edges[1].Target != "web" || doc.Edges[1].Weight != "2" {
+		t.Errorf("edges: got %v", doc.Edges)
+	}
+}
+
+func TestSingletons(t *testing.T) {
+	in := []pinboard.Tag{{Name: "zap", Count: 1}, {Name: "foo", Count: 27},
+		{Name: "bar", Count: 1}}
+
+	got := pinboard.Singletons(in)
+
+	if len(got) != 2 || got[0].Name != "bar" || got[1].Name != "zap" {
+		t.Errorf("singletons: got %v want [bar zap]", got)
+	}
+}
+
+func TestSimilarTags(t *testing.T) {
+	in := []pinboard.Tag{
+		{Name: "Recipe", Count: 2},
+		{Name: "recipes", Count: 10},
+		{Name: "javascript", Count: 5},
+		{Name: "javscript", Count: 1},
+		{Name: "go", Count: 9},
+		{Name: "js", Count: 3},
+	}
+
+	got := pinboard.SimilarTags(in, 1)
+
+	if len(got) != 2 {
+		t.Fatalf("groups: got %v want 2", got)
+	}
+	if len(got[0]) != 2 || got[0][0].Name != "javascript" ||
+		got[0][1].Name != "javscript" {
+		t.Errorf("group 0: got %v want [javascript javscript]", got[0])
+	}
+	if len(got[1]) != 2 || got[1][0].Name != "recipes" ||
+		got[1][1].Name != "Recipe" {
+		t.Errorf("group 1: got %v want [recipes Recipe]", got[1])
+	}
+
+	got = pinboard.SimilarTags(in, 0)
+
+	if len(got) != 1 {
+		t.Errorf("groups: got %v want 1", got)
+	}
+}
+
+func TestSimilarTagsPlurals(t *testing.T) {
+	in := []pinboard.Tag{
+		{Name: "database", Count: 4},
+		{Name: "databases", Count: 3},
+		{Name: "case", Count: 2},
+		{Name: "cases", Count: 1},
+		{Name: "cache", Count: 2},
+		{Name: "caches", Count: 1},
+		{Name: "new", Count: 5},
+		{Name: "news", Count: 6},
+		{Name: "status", Count: 1},
+		{Name: "statu", Count: 1},
+	}
+
+	got := pinboard.SimilarTags(in, 0)
+
+	want := "[[{cache 2} {caches 1}] [{case 2} {cases 1}] [{database 4} {databases 3}]]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("groups: got %v want %s", got, want)
+	}
+}
+
+func TestTagUsage(t *testing.T) {
+	got := pinboard.TagUsage(graphBookmarks)
+
+	web := got["web"]
+	want := []pinboard.Post{
+		{Date: time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC), Count: 2},
+		{Date: time.Date(2015, 7, 3, 0, 0, 0, 0, time.UTC), Count: 1},
+	}
+	if len(web) != len(want) {
+		t.Fatalf("usage: got %v want %v", web, want)
+	}
+	for i := range want {
+		if web[i] != want[i] {
+			t.Errorf("usage: got %v want %v", web, want)
+		}
+	}
+	if len(got["go"]) != 1 || got["go"][0].Count != 2 {
+		t.Errorf("usage: got %v want 1 day with 2 bookmarks", got["go"])
+	}
+}
@@ -0,0 +1,201 @@
+package pinboard_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/umahmood/pinboard"
+)
+
+const archivePage = `<!DOCTYPE html>
+<html><head><title>Page</title>
+<link rel="stylesheet" href="/style.css">
+<style>body { background: url('/bg.png') }</style>
+<script src="app.js" async></script>
+</head><body>
+<img src="/logo.png" srcset="/logo2x.png 2x" alt="Logo &amp; co">
+<img src="/missing.png">
+<a href="/about">About</a>
+</body></html>`
+
+// startArchiveServer starts a server with a page and its resources, hits
+// counts the requests to each path.
+func startArchiveServer() (*httptest.Server, map[string]int, *sync.Mutex) {
+	hits := make(map[string]int)
+	var mu sync.Mutex
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, archivePage)
+		case "/style.css":
+			w.Header().Set("Content-Type", "text/css")
+			fmt.Fprint(w, `h1 { background: url("img/h1.png") }`)
+		case "/img/h1.png", "/bg.png", "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+			fmt.Fprint(w, "PNG"+r.URL.Path)
+		case "/app.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			fmt.Fprint(w, "alert(1)")
+		case "/file.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			fmt.Fprint(w, "%PDF")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return ts, hits, &mu
+}
+
+// dataURI returns s as a base64 data URI.
+func dataURI(ct, s string) string {
+	return "data:" + ct + ";base64," + base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func TestArchiveSingleFile(t *testing.T) {
+	ts, _, _ := startArchiveServer()
+	defer ts.Close()
+
+	a := pinboard.Archiver{Dir: t.TempDir()}
+	b := pinboard.Bookmark{URL: ts.URL + "/page", Hash: []byte("5D41402ABC4B2A76B9719D911017C592")}
+	s, err := a.Archive(b)
+	if err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if !s.OK() || s.Key != "5d41402abc4b2a76b9719d911017c592" || s.Resources != 5 || s.Failed != 1 {
+		t.Errorf("status: got %+v", s)
+	}
+	if s.File != filepath.Join("5d", "5d41402abc4b2a76b9719d911017c592", "index.html") {
+		t.Errorf("file: got %s", s.File)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(a.Dir, s.File))
+	if err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	doc := string(data)
+	for _, want := range []string{
+		`<img alt="Logo &amp; co" src="` + dataURI("image/png", "PNG/logo.png") + `">`,
+		`<script async="" src="` + dataURI("application/javascript", "alert(1)") + `"></script>`,
+		`<link href="` + dataURI("text/css", `h1 { background: url("`+
+			dataURI("image/png", "PNG/img/h1.png")+`") }`) + `" rel="stylesheet">`,
+		`body { background: url("` + dataURI("image/png", "PNG/bg.png") + `") }`,
+		`<img src="/missing.png">`,
+		`<a href="/about">About</a>`,
+		"<!DOCTYPE html>\n<html><head><title>Page</title>",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("snapshot: %s does not contain %s", doc, want)
+		}
+	}
+
+	got, ok, err := a.Status(b)
+	if err != nil || !ok || got.Digest != s.Digest || got.Digest == "" {
+		t.Errorf("status: got %+v, %v, %v want %+v", got, ok, err, s)
+	}
+}
+
+func TestArchiveWARC(t *testing.T) {
+	ts, _, _ := startArchiveServer()
+	defer ts.Close()
+
+	a := pinboard.Archiver{Dir: t.TempDir(), Format: pinboard.WARC}
+	// no Hash, the MD5 of the URL is used.
+	b := pinboard.Bookmark{URL: ts.URL + "/page"}
+	s, err := a.Archive(b)
+	if err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if !strings.HasSuffix(s.File, "archive.warc") || a.Path(b) != filepath.Join(a.Dir, s.File) {
+		t.Errorf("file: got %s want archive.warc at %s", s.File, a.Path(b))
+	}
+	data, err := ioutil.ReadFile(a.Path(b))
+	if err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	warc := string(data)
+	if n := strings.Count(warc, "WARC/1.1\r\n"); n != 8 {
+		t.Errorf("records: got %d want 8", n)
+	}
+	for _, want := range []string{
+		"WARC-Type: warcinfo\r\n",
+		"WARC-Target-URI: " + ts.URL + "/page\r\n",
+		"WARC-Target-URI: " + ts.URL + "/img/h1.png\r\n",
+		"HTTP/1.1 404 Not Found\r\n",
+		archivePage,
+	} {
+		if !strings.Contains(warc, want) {
+			t.Errorf("warc: does not contain %q", want)
+		}
+	}
+}
+
+func TestArchiveFailures(t *testing.T) {
+	ts, _, _ := startArchiveServer()
+	defer ts.Close()
+
+	a := pinboard.Archiver{Dir: t.TempDir()}
+	tests := []struct {
+		url       string
+		status    int
+		wantError string
+	}{
+		{ts.URL + "/gone", 404, "HTTP 404 Not Found"},
+		{ts.URL + "/file.pdf", 200, "not an HTML page: application/pdf"},
+	}
+	for _, test := range tests {
+		b := pinboard.Bookmark{URL: test.url}
+		s, err := a.Archive(b)
+		if err != nil {
+			t.Errorf("error: got %v want nil", err)
+		}
+		if s.OK() || s.Status != test.status || s.Error != test.wantError {
+			t.Errorf("status: got %+v want %d %s", s, test.status, test.wantError)
+		}
+		got, ok, _ := a.Status(b)
+		if !ok || got.Error != test.wantError {
+			t.Errorf("saved status: got %+v", got)
+		}
+		if _, err := os.Stat(a.Path(b)); !os.IsNotExist(err) {
+			t.Errorf("snapshot: got %v want none", err)
+		}
+	}
+
+	_, ok, err := a.Status(pinboard.Bookmark{URL: "https://never.archived/"})
+	if ok || err != nil {
+		t.Errorf("status: got %v, %v want false, nil", ok, err)
+	}
+}
+
+func TestArchiveAll(t *testing.T) {
+	ts, hits, mu := startArchiveServer()
+	defer ts.Close()
+
+	a := pinboard.Archiver{Dir: t.TempDir()}
+	bmarks := []pinboard.Bookmark{{URL: ts.URL + "/page"}, {URL: ts.URL + "/gone"}}
+	for i := 0; i < 2; i++ {
+		all, err := a.ArchiveAll(bmarks)
+		if err != nil {
+			t.Errorf("error: got %v want nil", err)
+		}
+		if len(all) != 2 || !all[0].OK() || all[1].OK() {
+			t.Errorf("statuses: got %+v", all)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	// archived pages are skipped, failed ones are retried.
+	if hits["/page"] != 1 || hits["/gone"] != 2 {
+		t.Errorf("hits: got %v", hits)
+	}
+}
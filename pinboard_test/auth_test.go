@@ -0,0 +1,273 @@
+package pinboard_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+// tokenServer is a Pinboard server which only accepts the tokens in valid,
+// so tests can reset a token.
+type tokenServer struct {
+	mu    sync.Mutex
+	valid map[string]bool
+}
+
+// startTokenServer starts a tokenServer accepting tokens.
+func startTokenServer(tokens ...string) (*httptest.Server, *tokenServer) {
+	s := &tokenServer{valid: make(map[string]bool)}
+	for _, t := range tokens {
+		s.valid[t] = true
+	}
+	ts := httptest.NewServer(s)
+	pinboard.BaseURL = ts.URL + "/%s/?%s"
+	return ts, s
+}
+
+// reset replaces token old with token new.
+func (s *tokenServer) reset(old, new string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.valid, old)
+	s.valid[new] = true
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	ok := s.valid[r.URL.Query().Get("auth_token")]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch r.URL.Path {
+	case "/user/api_token/":
+		fmt.Fprint(w, `{"result":"0123456789"}`)
+	case "/posts/update/":
+		fmt.Fprint(w, `{"update_time":"2015-07-02T17:03:45Z"}`)
+	}
+}
+
+// tokenProvider returns an OnAuthExpired callback returning token, counting
+// its calls.
+func tokenProvider(token string, calls *int) func(string) (string, error) {
+	var mu sync.Mutex
+	return func(string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		*calls++
+		return token, nil
+	}
+}
+
+func TestAuthExpiredRetry(t *testing.T) {
+	ts, s := startTokenServer("me:old")
+	defer ts.Close()
+
+	pin := authed(t, "me:old")
+	h := &recordingHooks{}
+	pin.SetHooks(h)
+	var expired []string
+	pin.OnAuthExpired(func(token string) (string, error) {
+		expired = append(expired, token)
+		return "me:new", nil
+	})
+	s.reset("me:old", "me:new")
+
+	if _, err := pin.LastUpdate(); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if fmt.Sprint(expired) != "[me:old]" {
+		t.Errorf("expired: got %v want [me:old]", expired)
+	}
+	if pin.Token() != "me:new" || !pin.IsAuthed() {
+		t.Errorf("token: got %s %t want me:new true", pin.Token(), pin.IsAuthed())
+	}
+	retries := 0
+	for _, c := range h.calls {
+		if c == "retry posts/update 1" {
+			retries++
+		}
+	}
+	if retries != 1 {
+		t.Errorf("calls: got %v want 1 retry", h.calls)
+	}
+}
+
+func TestAuthExpiredWithoutCallback(t *testing.T) {
+	ts, s := startTokenServer("me:old")
+	defer ts.Close()
+
+	pin := authed(t, "me:old")
+	s.reset("me:old", "me:new")
+
+	_, err := pin.LastUpdate()
+	var se *pinboard.StatusError
+	if !errors.As(err, &se) || se.Code != http.StatusUnauthorized {
+		t.Fatalf("error: got %v want *StatusError 401", err)
+	}
+	if err.Error() != "HTTP 401 Unauthorized" {
+		t.Errorf("error: got %s want HTTP 401 Unauthorized", err)
+	}
+	if pin.IsAuthed() {
+		t.Error("authed: got true want false")
+	}
+	if _, err := pin.LastUpdate(); err == nil || err.Error() != "API not authorized" {
+		t.Errorf("error: got %v want API not authorized", err)
+	}
+
+	// authenticating again recovers.
+	if _, err := pin.Auth("me:new"); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if _, err := pin.LastUpdate(); err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+}
+
+func TestAuthExpiredFreshTokenRejected(t *testing.T) {
+	ts, s := startTokenServer("me:old")
+	defer ts.Close()
+
+	pin := authed(t, "me:old")
+	calls := 0
+	pin.OnAuthExpired(tokenProvider("me:bad", &calls))
+	s.reset("me:old", "me:new")
+
+	if _, err := pin.LastUpdate(); !isStatus(err, http.StatusUnauthorized) {
+		t.Fatalf("error: got %v want HTTP 401", err)
+	}
+	if calls != 1 || pin.IsAuthed() {
+		t.Errorf("calls: got %d authed %t want 1 false", calls, pin.IsAuthed())
+	}
+
+	// the next request asks for a token again, which is good this time.
+	pin.OnAuthExpired(tokenProvider("me:new", &calls))
+	if _, err := pin.LastUpdate(); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if calls != 2 || pin.Token() != "me:new" {
+		t.Errorf("calls: got %d token %s want 2 me:new", calls, pin.Token())
+	}
+}
+
+func TestAuthExpiredConcurrent(t *testing.T) {
+	ts, s := startTokenServer("me:old")
+	defer ts.Close()
+
+	pin := authed(t, "me:old")
+	calls := 0
+	pin.OnAuthExpired(tokenProvider("me:new", &calls))
+	s.reset("me:old", "me:new")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pin.LastUpdate(); err != nil {
+				t.Errorf("error: got %v want nil", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("calls: got %d want 1", calls)
+	}
+}
+
+func TestAuthExpiredCallbackAuth(t *testing.T) {
+	ts, s := startTokenServer("me:old")
+	defer ts.Close()
+
+	pin := authed(t, "me:old")
+	pin.OnAuthExpired(func(token string) (string, error) {
+		// checking candidate tokens with Auth must not deadlock.
+		if _, err := pin.Auth("me:wrong"); err == nil {
+			t.Error("auth: got nil want error")
+		}
+		if _, err := pin.Auth("me:new"); err != nil {
+			return "", err
+		}
+		return pin.Token(), nil
+	})
+	s.reset("me:old", "me:new")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := pin.LastUpdate()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("error: got %v want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlocked")
+	}
+	if pin.Token() != "me:new" {
+		t.Errorf("token: got %s want me:new", pin.Token())
+	}
+}
+
+// isStatus reports whether err is a *StatusError with code.
+func isStatus(err error, code int) bool {
+	var se *pinboard.StatusError
+	return errors.As(err, &se) && se.Code == code
+}
+
+func TestNewWithToken(t *testing.T) {
+	ts, f := startFakeAccounts(map[string][]fakePost{"me:1": {post("https://a.com", "A", "x")}})
+	defer ts.Close()
+
+	pin := pinboard.NewWithToken("me:1")
+	if !pin.IsAuthed() || pin.Token() != "me:1" {
+		t.Errorf("new: got %t %s want true me:1", pin.IsAuthed(), pin.Token())
+	}
+	bmarks, err := pin.Recent(nil, 10)
+	if err != nil || len(bmarks) != 1 {
+		t.Fatalf("recent: got %d, %v want 1, nil", len(bmarks), err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.calls["/user/api_token/"] != 0 {
+		t.Errorf("user/api_token: got %d calls want 0", f.calls["/user/api_token/"])
+	}
+}
+
+func TestNewWithTokenRejected(t *testing.T) {
+	ts, _ := startTokenServer("me:good")
+	defer ts.Close()
+
+	pin := pinboard.NewWithToken("me:bad")
+	_, err := pin.LastUpdate()
+	var ae *pinboard.AuthError
+	if !errors.As(err, &ae) || ae.User != "me" || !isStatus(err, http.StatusUnauthorized) {
+		t.Fatalf("error: got %#v want *AuthError for me with HTTP 401", err)
+	}
+	if err.Error() != "HTTP 401 Unauthorized" {
+		t.Errorf("error: got %s want HTTP 401 Unauthorized", err)
+	}
+	if pin.IsAuthed() {
+		t.Error("authed: got true want false")
+	}
+	_, err = pin.LastUpdate()
+	if !errors.As(err, &ae) || !errors.Is(err, pinboard.ErrNotAuthorized) {
+		t.Errorf("error: got %v want *AuthError wrapping ErrNotAuthorized", err)
+	}
+
+	// explicit validation is still available.
+	if _, err := pin.Auth("me:bad"); !errors.As(err, &ae) {
+		t.Errorf("auth: got %v want *AuthError", err)
+	}
+	if _, err := pin.Auth("me:good"); err != nil {
+		t.Errorf("auth: got %v want nil", err)
+	}
+}
@@ -0,0 +1,144 @@
+package pinboard_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	ts, f := startFakeAccounts(map[string][]fakePost{
+		"mango:0123456789": {post("https://a.com", "A", "x y"), post("https://b.com", "B", "y")},
+	})
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	pin := pinboard.New()
+	pin.SetHTTPClient(&http.Client{Transport: &pinboard.Recorder{Path: path, Mode: pinboard.Record}})
+	if _, err := pin.Auth("mango:0123456789"); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	recorded, err := pin.Bookmarks([]string{"y"}, 0, 0, time.Time{}, time.Time{}, false)
+	if err != nil || len(recorded) != 2 {
+		t.Fatalf("bookmarks: got %d, %v want 2, nil", len(recorded), err)
+	}
+	ts.Close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "0123456789") {
+		t.Errorf("cassette: got %s want auth token scrubbed", data)
+	}
+
+	// the server is gone, so everything must come from the cassette.
+	pin = pinboard.New()
+	pin.SetHTTPClient(&http.Client{Transport: &pinboard.Recorder{Path: path}})
+	if _, err := pin.Auth("mango:0123456789"); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	replayed, err := pin.Bookmarks([]string{"y"}, 0, 0, time.Time{}, time.Time{}, false)
+	if err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if len(replayed) != len(recorded) {
+		t.Fatalf("bookmarks: got %d want %d", len(replayed), len(recorded))
+	}
+	for i := range replayed {
+		if err := compareBookmarks(replayed[i], recorded[i]); err != nil {
+			t.Error(err)
+		}
+	}
+	if f.calls["/posts/all/"] != 1 {
+		t.Errorf("posts/all: got %d calls want 1", f.calls["/posts/all/"])
+	}
+
+	// a request which was never recorded.
+	if _, err := pin.Bookmarks([]string{"x"}, 0, 0, time.Time{}, time.Time{}, false); err == nil ||
+		!strings.Contains(err.Error(), "no interaction for GET /posts/all/") {
+		t.Errorf("error: got %v want no interaction", err)
+	}
+}
+
+func TestRecorderScrubsSecret(t *testing.T) {
+	ts, _ := startFakeAccounts(map[string][]fakePost{"mango:0123456789": nil})
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	pin := authed(t, "mango:0123456789")
+	pin.SetHTTPClient(&http.Client{Transport: &pinboard.Recorder{Path: path, Mode: pinboard.Record}})
+	secret, err := pin.Secret()
+	if err != nil || secret != "secret-mango" {
+		t.Fatalf("secret: got %s, %v want secret-mango, nil", secret, err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-mango") {
+		t.Errorf("cassette: got %s want secret scrubbed", data)
+	}
+}
+
+func TestRecorderReplayOrder(t *testing.T) {
+	ts, f := startFakeAccounts(map[string][]fakePost{"me:1": nil})
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	pin := authed(t, "me:1")
+	pin.SetHTTPClient(&http.Client{Transport: &pinboard.Recorder{Path: path, Mode: pinboard.Record}})
+	var want []time.Time
+	for _, u := range []string{"2015-07-01T00:00:00Z", "2015-07-02T00:00:00Z"} {
+		f.mu.Lock()
+		f.updated["me:1"] = u
+		f.mu.Unlock()
+		last, err := pin.LastUpdate()
+		if err != nil {
+			t.Fatalf("error: got %v want nil", err)
+		}
+		want = append(want, last)
+	}
+	ts.Close()
+
+	// replayed in order, then the last one repeats.
+	pin.SetHTTPClient(&http.Client{Transport: &pinboard.Recorder{Path: path}})
+	want = append(want, want[1])
+	for i, w := range want {
+		got, err := pin.LastUpdate()
+		if err != nil || !got.Equal(w) {
+			t.Errorf("update %d: got %v, %v want %v", i, got, err, w)
+		}
+	}
+}
+
+func TestRecorderFixture(t *testing.T) {
+	pinboard.BaseURL = "https://api.pinboard.in/v1/%s/?%s"
+
+	pin := pinboard.New()
+	pin.SetHTTPClient(&http.Client{Transport: &pinboard.Recorder{Path: "testdata/recent.json"}})
+	if _, err := pin.Auth("mango:0123456789"); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	bmarks, err := pin.Recent(nil, 2)
+	if err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if len(bmarks) != 2 {
+		t.Fatalf("bookmarks: got %d want 2", len(bmarks))
+	}
+	b := bmarks[0]
+	if b.URL != "https://www.example.com/caf%C3%A9" || b.Title != `Café — "menu" & prices` {
+		t.Errorf("bookmark: got %s %s", b.URL, b.Title)
+	}
+	if strings.Join(b.Tags, ",") != "food,東京" || !b.Shared || b.ToRead {
+		t.Errorf("bookmark: got tags %v shared %t to read %t", b.Tags, b.Shared, b.ToRead)
+	}
+	if bmarks[1].Desc != "line one\nline two" {
+		t.Errorf("desc: got %q want %q", bmarks[1].Desc, "line one\nline two")
+	}
+}
@@ -0,0 +1,152 @@
+package pinboard_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+func TestTagsSorted(t *testing.T) {
+	ts := startTestServer()
+	defer ts.Close()
+
+	pin := pinboard.New()
+	_, err := pin.Auth("mango:0123456789")
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	tests := []struct {
+		sort []pinboard.Sort
+		want []string
+	}{
+		{nil, []string{"bar", "foo", "zap", "zip"}},
+		{[]pinboard.Sort{{By: pinboard.ByName, Desc: true}}, []string{"zip", "zap", "foo", "bar"}},
+		{[]pinboard.Sort{{By: pinboard.ByCount}}, []string{"zip", "bar", "foo", "zap"}},
+		{[]pinboard.Sort{{By: pinboard.ByCount, Desc: true}}, []string{"zap", "foo", "bar", "zip"}},
+	}
+	for _, test := range tests {
+		// maps are ranged in a random order, repeat to catch unstable order.
+		for i := 0; i < 5; i++ {
+			got, err := pin.Tags(test.sort...)
+			if err != nil {
+				t.Errorf("error: got %v want nil", err)
+			}
+			if !reflect.DeepEqual(got.Names(), test.want) {
+				t.Errorf("tags %v: got %v want %v", test.sort, got.Names(), test.want)
+			}
+		}
+	}
+}
+
+func TestDatesSorted(t *testing.T) {
+	ts := startTestServer()
+	defer ts.Close()
+
+	pin := pinboard.New()
+	_, err := pin.Auth("mango:0123456789")
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	day := func(d int) time.Time { return time.Date(2015, 7, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		sort []pinboard.Sort
+		want pinboard.PostList
+	}{
+		{nil, pinboard.PostList{{Date: day(3), Count: 4}, {Date: day(2), Count: 2}, {Date: day(1), Count: 1}}},
+		{[]pinboard.Sort{{By: pinboard.ByDate}}, pinboard.PostList{{Date: day(1), Count: 1}, {Date: day(2), Count: 2}, {Date: day(3), Count: 4}}},
+		{[]pinboard.Sort{{By: pinboard.ByCount}}, pinboard.PostList{{Date: day(1), Count: 1}, {Date: day(2), Count: 2}, {Date: day(3), Count: 4}}},
+	}
+	for _, test := range tests {
+		for i := 0; i < 5; i++ {
+			got, err := pin.Dates(nil, test.sort...)
+			if err != nil {
+				t.Errorf("error: got %v want nil", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("dates %v: got %v want %v", test.sort, got, test.want)
+			}
+		}
+	}
+}
+
+func TestTagList(t *testing.T) {
+	l := pinboard.TagList{{Name: "go", Count: 5}, {Name: "Rust", Count: 2}, {Name: "c", Count: 5}, {Name: "zig", Count: 1}}
+
+	if got := l.Top(2).Names(); !reflect.DeepEqual(got, []string{"c", "go"}) {
+		t.Errorf("top: got %v want [c go]", got)
+	}
+	if got := l.Top(10); len(got) != 4 {
+		t.Errorf("top: got %v want all tags", got)
+	}
+	for _, n := range []int{0, -1} {
+		if got := l.Top(n); len(got) != 0 {
+			t.Errorf("top %d: got %v want no tags", n, got)
+		}
+	}
+	if got := l.MinCount(2).Names(); !reflect.DeepEqual(got, []string{"go", "Rust", "c"}) {
+		t.Errorf("min count: got %v want [go Rust c]", got)
+	}
+	if got, ok := l.Find("rust"); !ok || got.Name != "Rust" {
+		t.Errorf("find: got %v, %v want Rust", got, ok)
+	}
+	if _, ok := l.Find("java"); ok {
+		t.Errorf("find: got true want false")
+	}
+	// Top does not reorder the list.
+	if l[0].Name != "go" {
+		t.Errorf("list: got %v want unchanged", l)
+	}
+}
+
+func TestPostList(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2015, 7, d, 0, 0, 0, 0, time.UTC) }
+	l := pinboard.PostList{{Date: day(1), Count: 3}, {Date: day(2), Count: 1}, {Date: day(3), Count: 3}}
+
+	if got := l.Top(1); !reflect.DeepEqual(got, pinboard.PostList{{Date: day(1), Count: 3}}) {
+		t.Errorf("top: got %v want %v", got, pinboard.PostList{{Date: day(1), Count: 3}})
+	}
+	if got := l.Top(-1); len(got) != 0 {
+		t.Errorf("top: got %v want no dates", got)
+	}
+	if got := l.MinCount(2); len(got) != 2 {
+		t.Errorf("min count: got %v want 2 posts", got)
+	}
+	if got, ok := l.Find(day(2).Add(13 * time.Hour)); !ok || got.Count != 1 {
+		t.Errorf("find: got %v, %v want 1 post", got, ok)
+	}
+	if got := l.Total(); got != 7 {
+		t.Errorf("total: got %d want 7", got)
+	}
+}
+
+func TestSortBookmarks(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2015, 7, d, 0, 0, 0, 0, time.UTC) }
+	bmarks := []pinboard.Bookmark{
+		{URL: "https://c.com", Title: "B", Created: day(1), Tags: []string{"a"}},
+		{URL: "https://a.com", Title: "C", Created: day(3)},
+		{URL: "https://b.com", Title: "B", Created: day(2), Tags: []string{"a", "b"}},
+	}
+	tests := []struct {
+		sort pinboard.Sort
+		want []string
+	}{
+		{pinboard.Sort{}, []string{"https://b.com", "https://c.com", "https://a.com"}},
+		{pinboard.Sort{By: pinboard.ByName, Desc: true}, []string{"https://a.com", "https://b.com", "https://c.com"}},
+		{pinboard.Sort{By: pinboard.ByDate, Desc: true}, []string{"https://a.com", "https://b.com", "https://c.com"}},
+		{pinboard.Sort{By: pinboard.ByCount}, []string{"https://a.com", "https://c.com", "https://b.com"}},
+	}
+	for _, test := range tests {
+		pinboard.SortBookmarks(bmarks, test.sort)
+		var got []string
+		for _, b := range bmarks {
+			got = append(got, b.URL)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("sort %+v: got %v want %v", test.sort, got, test.want)
+		}
+	}
+}
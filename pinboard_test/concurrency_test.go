@@ -0,0 +1,80 @@
+package pinboard_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+// TestConcurrentRequests shares one client between many goroutines while the
+// token is rotated and settings changed, run it with -race.
+func TestConcurrentRequests(t *testing.T) {
+	posts := []fakePost{post("https://a.com", "A", "x"), post("https://b.com", "B", "y")}
+	ts, f := startFakeAccounts(map[string][]fakePost{"me:1": posts, "me:2": posts})
+	defer ts.Close()
+
+	pin := authed(t, "me:1")
+	stop := make(chan struct{})
+	var rotator sync.WaitGroup
+	rotator.Add(1)
+	go func() {
+		defer rotator.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, err := pin.Auth(fmt.Sprintf("me:%d", i%2+1)); err != nil {
+				t.Errorf("auth: got %v want nil", err)
+			}
+			pin.SetHooks(&recordingHooks{})
+			pin.SetURLPolicy(&pinboard.DefaultURLPolicy)
+		}
+	}()
+
+	var workers sync.WaitGroup
+	for g := 0; g < 20; g++ {
+		workers.Add(1)
+		go func(g int) {
+			defer workers.Done()
+			for i := 0; i < 10; i++ {
+				if _, err := pin.LastUpdate(); err != nil {
+					t.Errorf("last update: got %v want nil", err)
+				}
+				bmarks, err := pin.Bookmarks(nil, 0, 0, time.Time{}, time.Time{}, false)
+				if err != nil || len(bmarks) < 2 {
+					t.Errorf("bookmarks: got %d, %v want at least 2, nil", len(bmarks), err)
+				}
+				b := pinboard.Bookmark{URL: fmt.Sprintf("https://%d-%d.com", g, i)}
+				if _, err := pin.Add(b); err != nil {
+					t.Errorf("add: got %v want nil", err)
+				}
+				if tok := pin.Token(); tok != "me:1" && tok != "me:2" {
+					t.Errorf("token: got %s want me:1 or me:2", tok)
+				}
+				if !pin.IsAuthed() {
+					t.Error("authed: got false want true")
+				}
+			}
+		}(g)
+	}
+	workers.Wait()
+	close(stop)
+	rotator.Wait()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.adds) != 200 {
+		t.Errorf("adds: got %d want 200", len(f.adds))
+	}
+	for _, a := range f.adds {
+		if !strings.HasPrefix(a, "me:1 ") && !strings.HasPrefix(a, "me:2 ") {
+			t.Errorf("add: got %s want token me:1 or me:2", a)
+		}
+	}
+}
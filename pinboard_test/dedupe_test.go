@@ -0,0 +1,158 @@
+package pinboard_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://www.eff.org/", "eff.org"},
+		{"http://eff.org", "eff.org"},
+		{"HTTPS://EFF.org:443/issues/", "eff.org/issues"},
+		{"http://eff.org:8080/", "eff.org:8080"},
+		{"https://eff.org/a?utm_source=tw&utm_medium=x&id=2", "eff.org/a?id=2"},
+		{"https://eff.org/a?b=2&a=1#top", "eff.org/a?a=1&b=2"},
+		{"https://eff.org/a?fbclid=123", "eff.org/a"},
+		{"mailto:a@b.com", "mailto:a@b.com"},
+		{" javascript:void(0)", "javascript:void(0)"},
+	}
+	for _, test := range tests {
+		got, err := pinboard.CanonicalURL(test.in)
+		if err != nil {
+			t.Errorf("error: got %v want nil", err)
+		}
+		if got != test.want {
+			t.Errorf("canonical url %s: got %s want %s", test.in, got, test.want)
+		}
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	in := []pinboard.Bookmark{
+		{URL: "https://eff.org/"},
+		{URL: "https://golang.org/"},
+		{URL: "http://www.eff.org?utm_source=feed"},
+		{URL: "https://pinboard.in/"},
+		{URL: "https://golang.org"},
+	}
+
+	got := pinboard.Duplicates(in)
+
+	if len(got) != 2 {
+		t.Fatalf("groups: got %d want 2", len(got))
+	}
+	if len(got[0]) != 2 || got[0][0].URL != in[0].URL || got[0][1].URL != in[2].URL {
+		t.Errorf("group 0: got %v want [%v %v]", got[0], in[0], in[2])
+	}
+	if len(got[1]) != 2 || got[1][0].URL != in[1].URL || got[1][1].URL != in[4].URL {
+		t.Errorf("group 1: got %v want [%v %v]", got[1], in[1], in[4])
+	}
+}
+
+func TestCanonicalURLNoHost(t *testing.T) {
+	if got, err := pinboard.CanonicalURL("/just/a/path"); err == nil {
+		t.Errorf("canonical url: got %s, nil want error", got)
+	}
+}
+
+func TestDuplicatesOpaque(t *testing.T) {
+	in := []pinboard.Bookmark{
+		{URL: "mailto:a@b.com"},
+		{URL: "javascript:void(0)"},
+		{URL: "data:text/plain,hi"},
+		{URL: "/no/host"},
+		{URL: "/no/host"},
+		{URL: "mailto:a@b.com"},
+	}
+
+	got := pinboard.Duplicates(in)
+
+	if len(got) != 1 || len(got[0]) != 2 || got[0][0].URL != "mailto:a@b.com" {
+		t.Errorf("groups: got %v want [[mailto:a@b.com mailto:a@b.com]]", got)
+	}
+}
+
+func TestPlanMerge(t *testing.T) {
+	in := []pinboard.Bookmark{
+		{URL: "https://eff.org/",
+			Title:   "EFF",
+			Desc:    "digital rights",
+			Tags:    []string{"privacy", "rights"},
+			Created: time.Date(2015, 7, 2, 0, 0, 0, 0, time.UTC),
+			Shared:  true,
+		},
+		{URL: "http://www.eff.org",
+			Title:   "Electronic Frontier Foundation",
+			Desc:    "defending your rights",
+			Tags:    []string{"rights", "eff"},
+			Created: time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC),
+			Shared:  false,
+			ToRead:  true,
+		},
+	}
+
+	got := pinboard.PlanMerge(in)
+
+	want := pinboard.Bookmark{
+		URL:     "http://www.eff.org",
+		Title:   "Electronic Frontier Foundation",
+		Desc:    "defending your rights\n\ndigital rights",
+		Tags:    []string{"rights", "eff", "privacy"},
+		Created: time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC),
+		Shared:  false,
+		ToRead:  true,
+	}
+	if err := compareBookmarks(got.Keep, want); err != nil {
+		t.Errorf("error: %v", err)
+	}
+	if !got.Keep.Replace {
+		t.Errorf("replace: got false want true")
+	}
+	if len(got.Delete) != 1 || got.Delete[0] != "https://eff.org/" {
+		t.Errorf("delete: got %v want [https://eff.org/]", got.Delete)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	ts, f := startFakeAccounts(map[string][]fakePost{
+		"me:1": {post("https://eff.org", "EFF", "privacy"), post("http://www.eff.org", "EFF", "rights")},
+	})
+	defer ts.Close()
+
+	for _, policy := range []*pinboard.URLPolicy{nil, &pinboard.DefaultURLPolicy} {
+		f.mu.Lock()
+		f.accounts["me:1"] = []fakePost{post("https://eff.org", "EFF", "privacy"),
+			post("http://www.eff.org", "EFF", "rights")}
+		f.mu.Unlock()
+
+		pin := authed(t, "me:1")
+		pin.SetURLPolicy(policy)
+		bmarks, err := pin.Bookmarks(nil, 0, 0, time.Time{}, time.Time{}, false)
+		if err != nil {
+			t.Fatalf("error: got %v want nil", err)
+		}
+		dups := pinboard.Duplicates(bmarks)
+		if len(dups) != 1 {
+			t.Fatalf("duplicates: got %v want 1 group", dups)
+		}
+		plan := pinboard.PlanMerge(dups[0])
+
+		got, err := pin.Merge(plan)
+
+		if err != nil || !got {
+			t.Errorf("merge %v: got %v, %v want true, nil", policy, got, err)
+		}
+		f.mu.Lock()
+		posts := f.accounts["me:1"]
+		if len(posts) != 1 || posts[0].Tags != "privacy rights" && posts[0].Tags != "rights privacy" {
+			t.Errorf("merge %v: got %+v want one bookmark with all tags", policy, posts)
+		}
+		f.mu.Unlock()
+	}
+}
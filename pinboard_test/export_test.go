@@ -0,0 +1,166 @@
+package pinboard_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+var exportBookmarks = []pinboard.Bookmark{
+	{URL: "https://a.com/", Title: "A, \"quoted\"", Desc: "line\nbreak",
+		Tags:    []string{"go", "c++"},
+		Created: time.Date(2015, 7, 13, 10, 0, 0, 0, time.UTC),
+		Shared:  true,
+		Hash:    []byte("0123"),
+		Meta:    []byte("4567")},
+	{URL: "https://b.com/", Title: "B", ToRead: true},
+}
+
+func TestExportBookmarksRoundTrip(t *testing.T) {
+	for _, format := range []pinboard.Format{pinboard.CSV, pinboard.JSONLines} {
+		for _, tags := range []pinboard.TagEncoding{pinboard.TagsSpace,
+			pinboard.TagsComma, pinboard.TagsJSON} {
+			opt := pinboard.ExportOptions{Format: format, Tags: tags}
+			var buf bytes.Buffer
+			if err := pinboard.WriteBookmarks(&buf, exportBookmarks, opt); err != nil {
+				t.Errorf("error: got %v want nil", err)
+			}
+			got, err := pinboard.ReadBookmarks(&buf, opt)
+			if err != nil {
+				t.Errorf("error: got %v want nil", err)
+			}
+			if !reflect.DeepEqual(got, exportBookmarks) {
+				t.Errorf("%+v: got %+v want %+v", opt, got, exportBookmarks)
+			}
+		}
+	}
+}
+
+func TestExportBookmarksCSV(t *testing.T) {
+	var buf bytes.Buffer
+	opt := pinboard.ExportOptions{Tags: pinboard.TagsJSON}
+	if err := pinboard.WriteBookmarks(&buf, exportBookmarks[1:], opt); err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	want := "url,title,description,tags,created,shared,toread,hash,meta\n" +
+		"https://b.com/,B,,[],,false,true,,\n"
+	if buf.String() != want {
+		t.Errorf("csv: got %q want %q", buf.String(), want)
+	}
+}
+
+func TestExportBookmarksJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	opt := pinboard.ExportOptions{Format: pinboard.JSONLines, Tags: pinboard.TagsJSON}
+	if err := pinboard.WriteBookmarks(&buf, exportBookmarks, opt); err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("lines: got %d want 2", len(lines))
+	}
+	want := `{"created":"2015-07-13T10:00:00Z","description":"line\nbreak",` +
+		`"hash":"0123","meta":"4567","shared":true,"tags":["go","c++"],` +
+		`"title":"A, \"quoted\"","toread":false,"url":"https://a.com/"}`
+	if lines[0] != want {
+		t.Errorf("json: got %s want %s", lines[0], want)
+	}
+	if !strings.Contains(lines[1], `"created":null`) {
+		t.Errorf("json: got %s want created null", lines[1])
+	}
+}
+
+func TestExportTagsPostsNotes(t *testing.T) {
+	tags := []pinboard.Tag{{Name: "go", Count: 3}, {Name: "c++", Count: 1}}
+	posts := []pinboard.Post{{Date: time.Date(2015, 7, 13, 0, 0, 0, 0, time.UTC), Count: 2}}
+	notes := []pinboard.NoteMetadata{{ID: "8e5d6964bb810e0050b0", Title: "Note",
+		Length:  14,
+		Hash:    []byte("0123"),
+		Created: time.Date(2015, 7, 13, 10, 0, 0, 0, time.UTC),
+		Updated: time.Date(2015, 7, 14, 10, 0, 0, 0, time.UTC),
+	}}
+	for _, format := range []pinboard.Format{pinboard.CSV, pinboard.JSONLines} {
+		opt := pinboard.ExportOptions{Format: format}
+		var buf bytes.Buffer
+
+		pinboard.WriteTags(&buf, tags, opt)
+		gotTags, err := pinboard.ReadTags(&buf, opt)
+		if err != nil || !reflect.DeepEqual(gotTags, tags) {
+			t.Errorf("tags: got %+v, %v want %+v", gotTags, err, tags)
+		}
+
+		pinboard.WritePosts(&buf, posts, opt)
+		gotPosts, err := pinboard.ReadPosts(&buf, opt)
+		if err != nil || !reflect.DeepEqual(gotPosts, posts) {
+			t.Errorf("posts: got %+v, %v want %+v", gotPosts, err, posts)
+		}
+
+		pinboard.WriteNotes(&buf, notes, opt)
+		gotNotes, err := pinboard.ReadNotes(&buf, opt)
+		if err != nil || !reflect.DeepEqual(gotNotes, notes) {
+			t.Errorf("notes: got %+v, %v want %+v", gotNotes, err, notes)
+		}
+	}
+}
+
+func TestRecordWriterMixedKinds(t *testing.T) {
+	rw := pinboard.NewRecordWriter(&bytes.Buffer{}, pinboard.ExportOptions{})
+	if err := rw.WriteTag(pinboard.Tag{Name: "go"}); err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if err := rw.WritePost(pinboard.Post{}); err == nil {
+		t.Errorf("error: got nil want can't mix kinds of records")
+	}
+}
+
+func TestReadBookmarksBadColumn(t *testing.T) {
+	doc := "url,shared\nhttps://a.com/,maybe\n"
+	if _, err := pinboard.ReadBookmarks(strings.NewReader(doc), pinboard.ExportOptions{}); err == nil {
+		t.Errorf("error: got nil want column shared error")
+	}
+}
+
+func TestStreamBookmarks(t *testing.T) {
+	ts, _ := startFakeAccounts(map[string][]fakePost{
+		"me:1": {post("https://a.com", "A", "go web"), post("https://b.com", "B", "go")},
+	})
+	defer ts.Close()
+	pin := authed(t, "me:1")
+
+	var buf bytes.Buffer
+	opt := pinboard.ExportOptions{Format: pinboard.JSONLines}
+	rw := pinboard.NewRecordWriter(&buf, opt)
+	err := pin.StreamBookmarks(nil, time.Time{}, time.Time{}, false, rw.WriteBookmark)
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	got, err := pinboard.ReadBookmarks(&buf, opt)
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if len(got) != 2 || got[0].URL != "https://a.com" || !reflect.DeepEqual(got[0].Tags,
+		[]string{"go", "web"}) {
+		t.Errorf("bookmarks: got %+v", got)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = pin.StreamBookmarks(nil, time.Time{}, time.Time{}, false, func(pinboard.Bookmark) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("stop: got %v after %d want stop after 1", err, n)
+	}
+
+	pin = pinboard.New()
+	err = pin.StreamBookmarks(nil, time.Time{}, time.Time{}, false, rw.WriteBookmark)
+	if err == nil {
+		t.Errorf("error: got nil want API not authorized")
+	}
+}
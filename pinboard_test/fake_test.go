@@ -0,0 +1,141 @@
+package pinboard_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/umahmood/pinboard"
+)
+
+// fakePost is a bookmark stored by a fakeAccounts server.
+type fakePost struct {
+	Href        string `json:"href"`
+	Description string `json:"description"`
+	Extended    string `json:"extended"`
+	Meta        string `json:"meta"`
+	Hash        string `json:"hash"`
+	Time        string `json:"time"`
+	Shared      string `json:"shared"`
+	ToRead      string `json:"toread"`
+	Tags        string `json:"tags"`
+}
+
+// fakeAccounts is a Pinboard server keeping the bookmarks of each account, the
+// account is selected by the auth_token of each request. Every bookmark added
+// is recorded in adds and the number of requests to each path in calls.
+type fakeAccounts struct {
+	mu       sync.Mutex
+	accounts map[string][]fakePost
+	updated  map[string]string
+	adds     []string
+	calls    map[string]int
+}
+
+// startFakeAccounts starts a fakeAccounts server.
+func startFakeAccounts(accounts map[string][]fakePost) (*httptest.Server, *fakeAccounts) {
+	f := &fakeAccounts{accounts: accounts,
+		updated: make(map[string]string),
+		calls:   make(map[string]int),
+	}
+	ts := httptest.NewServer(f)
+	pinboard.BaseURL = ts.URL + "/%s/?%s"
+	return ts, f
+}
+
+func (f *fakeAccounts) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r.ParseForm()
+	q := r.Form
+	token := q.Get("auth_token")
+	f.calls[r.URL.Path]++
+	switch r.URL.Path {
+	case "/user/api_token/":
+		fmt.Fprint(w, `{"result":"0123456789"}`)
+	case "/user/secret/":
+		fmt.Fprintf(w, `{"result":"secret-%s"}`, strings.Split(token, ":")[0])
+	case "/posts/update/":
+		u := f.updated[token]
+		if u == "" {
+			u = "2015-07-01T00:00:00Z"
+		}
+		fmt.Fprintf(w, `{"update_time":"%s"}`, u)
+	case "/posts/all/":
+		var posts []fakePost
+		tag := q.Get("tag")
+		for _, p := range f.accounts[token] {
+			if tag == "" || strings.Contains(" "+p.Tags+" ", " "+tag+" ") {
+				posts = append(posts, p)
+			}
+		}
+		start, _ := strconv.Atoi(q.Get("start"))
+		if start > len(posts) {
+			start = len(posts)
+		}
+		posts = posts[start:]
+		if n, _ := strconv.Atoi(q.Get("results")); n > 0 && n < len(posts) {
+			posts = posts[:n]
+		}
+		if posts == nil {
+			posts = []fakePost{}
+		}
+		json.NewEncoder(w).Encode(posts)
+	case "/posts/recent/":
+		posts := f.accounts[token]
+		if posts == nil {
+			posts = []fakePost{}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"posts": posts})
+	case "/posts/delete/":
+		posts := f.accounts[token]
+		for i := range posts {
+			if posts[i].Href == q.Get("url") {
+				f.accounts[token] = append(posts[:i], posts[i+1:]...)
+				fmt.Fprint(w, `{"result_code":"done"}`)
+				return
+			}
+		}
+		fmt.Fprint(w, `{"result_code":"item not found"}`)
+	case "/posts/add/":
+		p := fakePost{Href: q.Get("url"),
+			Description: q.Get("description"),
+			Extended:    q.Get("extended"),
+			Time:        q.Get("dt"),
+			Shared:      q.Get("shared"),
+			ToRead:      q.Get("toread"),
+			Tags:        strings.Replace(q.Get("tags"), ",", " ", -1),
+		}
+		f.adds = append(f.adds, token+" "+p.Href)
+		posts := f.accounts[token]
+		for i := range posts {
+			if posts[i].Href == p.Href {
+				posts[i] = p
+				fmt.Fprint(w, `{"result_code":"done"}`)
+				return
+			}
+		}
+		f.accounts[token] = append(posts, p)
+		fmt.Fprint(w, `{"result_code":"done"}`)
+	}
+}
+
+// post returns a fakePost for url.
+func post(url, title, tags string) fakePost {
+	return fakePost{Href: url, Description: title, Time: "2015-07-01T12:00:00Z",
+		Shared: "no", ToRead: "no", Tags: tags}
+}
+
+// authed returns a client authenticated with token.
+func authed(t *testing.T, token string) *pinboard.Pinboard {
+	pin := pinboard.New()
+	if _, err := pin.Auth(token); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	return pin
+}
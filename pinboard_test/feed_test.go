@@ -0,0 +1,281 @@
+package pinboard_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+// testFeed a feed with two bookmarks.
+var testFeed = pinboard.Feed{Title: "Team links",
+	Link:   "https://example.com/links",
+	Desc:   "Links worth reading",
+	Author: "mango",
+	Bookmarks: []pinboard.Bookmark{
+		{URL: "https://eff.org/",
+			Title:   "EFF",
+			Desc:    "Defending <your> rights",
+			Tags:    []string{"privacy", "rights"},
+			Created: time.Date(2015, 7, 2, 7, 56, 40, 0, time.UTC),
+		},
+		{URL: "https://golang.org/",
+			Title:   "Go",
+			Created: time.Date(2015, 7, 1, 12, 0, 0, 0, time.UTC),
+		},
+	},
+}
+
+func TestFeedWriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	err := testFeed.WriteRSS(&buf)
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Title       string   `xml:"title"`
+				Link        string   `xml:"link"`
+				Description string   `xml:"description"`
+				Categories  []string `xml:"category"`
+				PubDate     string   `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if doc.Version != "2.0" || doc.Channel.Title != "Team links" {
+		t.Errorf("rss: got %+v", doc)
+	}
+	if doc.Channel.LastBuildDate != "Thu, 02 Jul 2015 07:56:40 +0000" {
+		t.Errorf("last build date: got %s", doc.Channel.LastBuildDate)
+	}
+	if len(doc.Channel.Items) != 2 {
+		t.Fatalf("items: got %d want 2", len(doc.Channel.Items))
+	}
+	i := doc.Channel.Items[0]
+	if i.Link != "https://eff.org/" || i.Description != "Defending <your> rights" ||
+		len(i.Categories) != 2 || i.Categories[1] != "rights" {
+		t.Errorf("item: got %+v", i)
+	}
+}
+
+func TestFeedWriteAtom(t *testing.T) {
+	var buf bytes.Buffer
+	err := testFeed.WriteAtom(&buf)
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	var doc struct {
+		XMLName xml.Name
+		ID      string `xml:"id"`
+		Updated string `xml:"updated"`
+		Entries []struct {
+			ID         string `xml:"id"`
+			Updated    string `xml:"updated"`
+			Categories []struct {
+				Term string `xml:"term,attr"`
+			} `xml:"category"`
+			Content string `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if doc.XMLName.Space != "http://www.w3.org/2005/Atom" || doc.XMLName.Local != "feed" {
+		t.Errorf("name: got %v", doc.XMLName)
+	}
+	if doc.ID != "https://example.com/links" || doc.Updated != "2015-07-02T07:56:40Z" {
+		t.Errorf("atom: got %+v", doc)
+	}
+	if len(doc.Entries) != 2 {
+		t.Fatalf("entries: got %d want 2", len(doc.Entries))
+	}
+	e := doc.Entries[1]
+	if e.ID != "https://golang.org/" || e.Updated != "2015-07-01T12:00:00Z" ||
+		len(e.Categories) != 0 || e.Content != "" {
+		t.Errorf("entry: got %+v", e)
+	}
+}
+
+func TestFeedWriteAtomDefaults(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (pinboard.Feed{Title: "Team links"}).WriteAtom(&buf); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	var doc struct {
+		ID     string `xml:"id"`
+		Author struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if doc.ID != "tag:pinboard.in,2009:feed/Team%20links" || doc.Author.Name != "Pinboard" {
+		t.Errorf("atom: got %+v", doc)
+	}
+
+	buf.Reset()
+	f := pinboard.Feed{ID: "urn:uuid:60a76c80", Author: "mango"}
+	if err := f.WriteAtom(&buf); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if doc.ID != "urn:uuid:60a76c80" || doc.Author.Name != "mango" {
+		t.Errorf("atom: got %+v", doc)
+	}
+}
+
+func TestFeedWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := testFeed.WriteJSON(&buf)
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	var doc struct {
+		Version string `json:"version"`
+		Items   []struct {
+			ID            string   `json:"id"`
+			ContentText   string   `json:"content_text"`
+			DatePublished string   `json:"date_published"`
+			Tags          []string `json:"tags"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if doc.Version != "https://jsonfeed.org/version/1.1" || len(doc.Items) != 2 {
+		t.Fatalf("json feed: got %+v", doc)
+	}
+	i := doc.Items[0]
+	if i.ID != "https://eff.org/" || i.ContentText != "Defending <your> rights" ||
+		i.DatePublished != "2015-07-02T07:56:40Z" || len(i.Tags) != 2 {
+		t.Errorf("item: got %+v", i)
+	}
+}
+
+func TestFeedHandler(t *testing.T) {
+	ts := startTestServer()
+	defer ts.Close()
+
+	pin := pinboard.New()
+	_, err := pin.Auth("mango:0123456789")
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	h := pinboard.FeedHandler{Pinboard: pin, Title: "Recent"}
+
+	r := httptest.NewRequest("GET", "/feed?tag=news&format=atom", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status: got %d want 200", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+		t.Errorf("content type: got %s", ct)
+	}
+	if lm := w.Header().Get("Last-Modified"); lm != "Thu, 02 Jul 2015 17:03:45 GMT" {
+		t.Errorf("last modified: got %s", lm)
+	}
+	if n := strings.Count(w.Body.String(), "<entry>"); n != 2 {
+		t.Errorf("entries: got %d want 2", n)
+	}
+
+	etag := w.Header().Get("ETag")
+	r = httptest.NewRequest("GET", "/feed?tag=news&format=atom", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("status: got %d want 304", w.Code)
+	}
+
+	r = httptest.NewRequest("GET", "/feed", nil)
+	r.Header.Set("If-Modified-Since", "Thu, 02 Jul 2015 17:03:45 GMT")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("status: got %d want 304", w.Code)
+	}
+
+	r = httptest.NewRequest("GET", "/feed?format=pdf", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status: got %d want 400", w.Code)
+	}
+}
+
+func TestFeedHandlerConditional(t *testing.T) {
+	ts := startTestServer()
+	defer ts.Close()
+
+	pin := pinboard.New()
+	if _, err := pin.Auth("mango:0123456789"); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	hooks := &recordingHooks{}
+	pin.SetHooks(hooks)
+
+	h := pinboard.FeedHandler{Pinboard: pin, Title: "Recent"}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/feed?format=atom", nil))
+	etag := w.Header().Get("ETag")
+	if !strings.Contains(w.Body.String(), "<name>mango</name>") {
+		t.Errorf("author: got %s want mango", w.Body.String())
+	}
+
+	for _, inm := range []string{`"abc", ` + etag, "W/" + etag, "*"} {
+		r := httptest.NewRequest("GET", "/feed?format=atom", nil)
+		r.Header.Set("If-None-Match", inm)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusNotModified {
+			t.Errorf("if none match %s: got %d want 304", inm, w.Code)
+		}
+	}
+
+	h.Count = 5
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("HEAD", "/feed?format=atom", nil))
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("head: got %d %s want 200 and a new etag", w.Code, w.Header().Get("ETag"))
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+		t.Errorf("content type: got %s", ct)
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	recent := 0
+	for _, e := range hooks.ends {
+		if e.Method == "posts/recent" {
+			recent++
+		}
+	}
+	if recent != 1 {
+		t.Errorf("posts/recent: got %d requests want 1", recent)
+	}
+}
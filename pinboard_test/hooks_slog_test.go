@@ -0,0 +1,40 @@
+//go:build go1.21
+
+package pinboard_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/umahmood/pinboard"
+)
+
+func TestSlogHooks(t *testing.T) {
+	ts := startTestServer()
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	pin := pinboard.New()
+	pin.SetHooks(pinboard.SlogHooks{Logger: l})
+	if _, err := pin.Auth("mango:0123456789"); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`level=DEBUG msg="pinboard request start"`,
+		`level=INFO msg="pinboard request end"`,
+		"method=user/api_token",
+		"status=200",
+		"auth_token=REDACTED",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log: got %s want %s", out, want)
+		}
+	}
+	if strings.Contains(out, "0123456789") {
+		t.Errorf("log: got %s want token redacted", out)
+	}
+}
@@ -0,0 +1,281 @@
+package pinboard_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+// recordingHooks records the calls made to each hook.
+type recordingHooks struct {
+	mu    sync.Mutex
+	calls []string
+	ends  []pinboard.RequestInfo
+}
+
+func (h *recordingHooks) record(s string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls = append(h.calls, s)
+}
+
+func (h *recordingHooks) RequestStart(r pinboard.RequestInfo) {
+	h.record(fmt.Sprintf("start %d %s", r.ID, r.Method))
+}
+
+func (h *recordingHooks) RequestEnd(r pinboard.RequestInfo) {
+	h.record(fmt.Sprintf("end %d %s %d", r.ID, r.Method, r.Status))
+	h.mu.Lock()
+	h.ends = append(h.ends, r)
+	h.mu.Unlock()
+}
+
+func (h *recordingHooks) Retry(r pinboard.RequestInfo, attempt int) {
+	h.record(fmt.Sprintf("retry %s %d", r.Method, attempt))
+}
+
+func (h *recordingHooks) RateLimitWait(method string, wait time.Duration) {
+	h.record("wait " + method)
+}
+
+func TestHooksRequest(t *testing.T) {
+	ts := startTestServer()
+	defer ts.Close()
+
+	h := &recordingHooks{}
+	pin := pinboard.New()
+	pin.SetHooks(h)
+	if _, err := pin.Auth("mango:0123456789"); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if _, err := pin.LastUpdate(); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if len(h.calls) != 4 {
+		t.Fatalf("calls: got %v want 4", h.calls)
+	}
+	id := h.ends[0].ID
+	want := fmt.Sprintf("[start %d user/api_token end %d user/api_token 200 "+
+		"start %d posts/update end %d posts/update 200]", id, id, id+1, id+1)
+	if fmt.Sprint(h.calls) != want {
+		t.Errorf("calls: got %v want %s", h.calls, want)
+	}
+	for _, r := range h.ends {
+		if strings.Contains(r.URL, "0123456789") {
+			t.Errorf("url: got %s want token redacted", r.URL)
+		}
+		if !strings.Contains(r.URL, "auth_token=REDACTED") {
+			t.Errorf("url: got %s want auth_token=REDACTED", r.URL)
+		}
+		if r.Bytes == 0 || r.Latency <= 0 || r.Start.IsZero() || r.Err != nil {
+			t.Errorf("end: got %+v want bytes, latency and no error", r)
+		}
+	}
+	if h.ends[1].Bytes != int64(len(`{"update_time":"2015-07-02T17:03:45Z"}`)) {
+		t.Errorf("bytes: got %d want %d", h.ends[1].Bytes,
+			len(`{"update_time":"2015-07-02T17:03:45Z"}`))
+	}
+}
+
+func TestHooksStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	pinboard.BaseURL = ts.URL + "/%s/?%s"
+
+	h := &recordingHooks{}
+	pin := pinboard.New()
+	pin.SetHooks(h)
+	if _, err := pin.Auth("mango:0123456789"); err == nil {
+		t.Fatal("error: got nil want HTTP 500")
+	}
+	if len(h.ends) != 1 {
+		t.Fatalf("ends: got %d want 1", len(h.ends))
+	}
+	r := h.ends[0]
+	if r.Status != 500 || r.Err == nil || r.Err.Error() != "HTTP 500 Internal Server Error" {
+		t.Errorf("end: got %d %v want 500 HTTP 500 Internal Server Error", r.Status, r.Err)
+	}
+}
+
+func TestHooksRedactError(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	pinboard.BaseURL = ts.URL + "/%s/?%s"
+	ts.Close()
+
+	h := &recordingHooks{}
+	pin := pinboard.New()
+	pin.SetHooks(h)
+	_, err := pin.Auth("mango:0123456789")
+	if err == nil {
+		t.Fatal("error: got nil want connection error")
+	}
+	if len(h.ends) != 1 {
+		t.Fatalf("ends: got %d want 1", len(h.ends))
+	}
+	r := h.ends[0]
+	if r.Status != 0 || r.Err == nil {
+		t.Fatalf("end: got %d %v want 0 and an error", r.Status, r.Err)
+	}
+	if strings.Contains(r.Err.Error(), "0123456789") || !strings.Contains(r.Err.Error(), "REDACTED") {
+		t.Errorf("error: got %v want token redacted", r.Err)
+	}
+}
+
+func TestHooksStream(t *testing.T) {
+	ts, _ := startFakeAccounts(pagerAccount(3))
+	defer ts.Close()
+
+	pin := authed(t, "me:1")
+	h := &recordingHooks{}
+	pin.SetHooks(h)
+	n := 0
+	err := pin.StreamBookmarks(nil, time.Time{}, time.Time{}, false, func(pinboard.Bookmark) error {
+		n++
+		return nil
+	})
+	if err != nil || n != 3 {
+		t.Fatalf("stream: got %d, %v want 3, nil", n, err)
+	}
+	if len(h.ends) != 1 || h.ends[0].Method != "posts/all" || h.ends[0].Bytes == 0 {
+		t.Errorf("ends: got %+v want one posts/all with bytes", h.ends)
+	}
+}
+
+func TestHooksRateLimitWait(t *testing.T) {
+	ts, _ := startFakeAccounts(pagerAccount(3))
+	defer ts.Close()
+
+	pin := authed(t, "me:1")
+	h := &recordingHooks{}
+	pin.SetHooks(h)
+	pg := &pinboard.Pager{Pinboard: pin, PageSize: 2, Wait: 10 * time.Millisecond}
+	if _, err := pg.All(); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	waits := 0
+	for _, c := range h.calls {
+		if c == "wait posts/all" {
+			waits++
+		}
+	}
+	if waits != 1 {
+		t.Errorf("waits: got %v want 1 wait", h.calls)
+	}
+}
+
+// fakeMetric records the values added to or observed by a metric by labels.
+type fakeMetric map[string]float64
+
+func (m fakeMetric) Add(v float64, labels ...string) {
+	m[strings.Join(labels, " ")] += v
+}
+
+func (m fakeMetric) Observe(v float64, labels ...string) {
+	m[strings.Join(labels, " ")] += v
+}
+
+func TestMetricsHooks(t *testing.T) {
+	ts := startTestServer()
+	defer ts.Close()
+
+	requests, latency, bytes, waits := fakeMetric{}, fakeMetric{}, fakeMetric{}, fakeMetric{}
+	m := pinboard.MetricsHooks{Requests: requests,
+		Latency: latency,
+		Bytes:   bytes,
+		Waits:   waits,
+	}
+	pin := pinboard.New()
+	pin.SetHooks(m)
+	pin.Auth("mango:0123456789")
+	pin.LastUpdate()
+	pin.LastUpdate()
+	m.RateLimitWait("posts/all", 3*time.Second)
+
+	if requests["posts/update 200"] != 2 || requests["user/api_token 200"] != 1 {
+		t.Errorf("requests: got %v want 2 posts/update and 1 user/api_token", requests)
+	}
+	if latency["posts/update 200"] <= 0 {
+		t.Errorf("latency: got %v want > 0", latency)
+	}
+	if bytes["posts/update"] != 2*float64(len(`{"update_time":"2015-07-02T17:03:45Z"}`)) {
+		t.Errorf("bytes: got %v", bytes)
+	}
+	if waits["posts/all"] != 3 {
+		t.Errorf("waits: got %v want 3", waits)
+	}
+}
+
+// fakeSpan is a span recorded by a fakeTracer.
+type fakeSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *fakeSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *fakeSpan) RecordError(err error)                      { s.err = err }
+func (s *fakeSpan) End()                                       { s.ended = true }
+
+// fakeTracer records the spans it starts.
+type fakeTracer struct {
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) Start(name string) pinboard.Span {
+	s := &fakeSpan{name: name, attrs: make(map[string]interface{})}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+func TestTracingHooks(t *testing.T) {
+	ts := startTestServer()
+	defer ts.Close()
+
+	tr := &fakeTracer{}
+	h := &pinboard.TracingHooks{Tracer: tr}
+	pin := pinboard.New()
+	pin.SetHooks(h)
+	pin.Auth("mango:0123456789")
+	h.Retry(pinboard.RequestInfo{Method: "posts/all"}, 1)
+
+	if len(tr.spans) != 2 {
+		t.Fatalf("spans: got %d want 2", len(tr.spans))
+	}
+	s := tr.spans[0]
+	if s.name != "pinboard user/api_token" || !s.ended || s.err != nil {
+		t.Errorf("span: got %s ended %t error %v want pinboard user/api_token ended", s.name,
+			s.ended, s.err)
+	}
+	if s.attrs["http.response.status_code"] != 200 {
+		t.Errorf("status: got %v want 200", s.attrs["http.response.status_code"])
+	}
+	if u, _ := s.attrs["url.full"].(string); strings.Contains(u, "0123456789") {
+		t.Errorf("url: got %s want token redacted", u)
+	}
+	if s := tr.spans[1]; s.name != "pinboard retry" || s.attrs["http.request.resend_count"] != 1 {
+		t.Errorf("retry: got %s %v", s.name, s.attrs)
+	}
+}
+
+func TestMultiHooks(t *testing.T) {
+	ts := startTestServer()
+	defer ts.Close()
+
+	a, b := &recordingHooks{}, &recordingHooks{}
+	pin := pinboard.New()
+	pin.SetHooks(pinboard.MultiHooks(a, pinboard.NopHooks{}, b))
+	pin.Auth("mango:0123456789")
+	if len(a.calls) != 2 || fmt.Sprint(a.calls) != fmt.Sprint(b.calls) {
+		t.Errorf("calls: got %v and %v want the same 2 calls", a.calls, b.calls)
+	}
+}
@@ -0,0 +1,190 @@
+package pinboard_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+func TestImportPocket(t *testing.T) {
+	doc := `<!DOCTYPE html>
+<html><head><title>Pocket Export</title></head><body>
+<h1>Unread</h1>
+<ul>
+<li><a href="https://a.com/" time_added="1436745600" tags="go,web dev,Go">A &amp; B</a></li>
+</ul>
+<h1>Read Archive</h1>
+<ul>
+<li><a href="https://b.com/" time_added="1436745600" tags="">B</a></li>
+</ul>
+</body></html>`
+	got, err := pinboard.ImportPocket(strings.NewReader(doc))
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	created := time.Date(2015, 7, 13, 0, 0, 0, 0, time.UTC)
+	want := []pinboard.Bookmark{
+		{URL: "https://a.com/", Title: "A & B", Tags: []string{"go", "web-dev"},
+			Created: created, ToRead: true},
+		{URL: "https://b.com/", Title: "B", Created: created},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bookmarks: got %+v want %+v", got, want)
+	}
+}
+
+func TestImportRaindrop(t *testing.T) {
+	doc := `id,title,note,excerpt,url,folder,tags,created,cover,highlights,favorite
+1,"Go, the language",,Excerpt,https://go.dev/,Dev/Go Lang,"go, Big Data",2015-07-13T10:00:00.000Z,,,false
+2,Unsorted link,A note,,https://b.com/,Unsorted,,,,,false
+3,No URL,,,,,,,,,false
+`
+	got, err := pinboard.ImportRaindrop(strings.NewReader(doc))
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	want := []pinboard.Bookmark{
+		{URL: "https://go.dev/", Title: "Go, the language", Desc: "Excerpt",
+			Tags:    []string{"go", "big-data", "dev", "go-lang"},
+			Created: time.Date(2015, 7, 13, 10, 0, 0, 0, time.UTC)},
+		{URL: "https://b.com/", Title: "Unsorted link", Desc: "A note"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bookmarks: got %+v want %+v", got, want)
+	}
+
+	if _, err := pinboard.ImportRaindrop(strings.NewReader("id,title\n")); err == nil {
+		t.Errorf("error: got nil want no url column")
+	}
+}
+
+func TestImportFirefox(t *testing.T) {
+	doc := `{"title":"","type":"text/x-moz-place-container","root":"placesRoot","children":[
+ {"title":"menu","type":"text/x-moz-place-container","root":"bookmarksMenuFolder","children":[
+  {"title":"Recent Tags","type":"text/x-moz-place","uri":"place:sort=14"},
+  {"title":"Reading","type":"text/x-moz-place-container","children":[
+   {"title":"A","type":"text/x-moz-place","uri":"https://a.com/","dateAdded":1436745600000000,"tags":"go"}
+  ]}
+ ]},
+ {"title":"toolbar","type":"text/x-moz-place-container","root":"toolbarFolder","children":[
+  {"title":"B","type":"text/x-moz-place","uri":"https://b.com/"}
+ ]}
+]}`
+	got, err := pinboard.ImportFirefox(strings.NewReader(doc))
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	want := []pinboard.Bookmark{
+		{URL: "https://a.com/", Title: "A", Tags: []string{"go", "reading"},
+			Created: time.Date(2015, 7, 13, 0, 0, 0, 0, time.UTC)},
+		{URL: "https://b.com/", Title: "B"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bookmarks: got %+v want %+v", got, want)
+	}
+}
+
+func TestImportChrome(t *testing.T) {
+	doc := `{"checksum":"x","roots":{
+ "bookmark_bar":{"name":"Bookmarks bar","type":"folder","children":[
+  {"name":"Dev Tools","type":"folder","children":[
+   {"name":"Go","type":"folder","children":[
+    {"name":"A","type":"url","url":"https://a.com/","date_added":"13081219200000000"}
+   ]}
+  ]},
+  {"name":"B","type":"url","url":"https://b.com/","date_added":"0"}
+ ]},
+ "other":{"name":"Other bookmarks","type":"folder","children":[]},
+ "synced":{"name":"Mobile bookmarks","type":"folder","children":[]}
+},"version":1}`
+	got, err := pinboard.ImportChrome(strings.NewReader(doc))
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	want := []pinboard.Bookmark{
+		{URL: "https://a.com/", Title: "A", Tags: []string{"dev-tools", "go"},
+			Created: time.Date(2015, 7, 13, 0, 0, 0, 0, time.UTC)},
+		{URL: "https://b.com/", Title: "B"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bookmarks: got %+v want %+v", got, want)
+	}
+}
+
+func TestImportMarkdown(t *testing.T) {
+	doc := `# Go
+Some text without links.
+## Testing
+- [Go testing](https://go.dev/doc/) - the basics #tutorial
+* <https://b.com/>
+# Misc
+1. [C](https://c.com/ "title") and [D](https://d.com/)
+`
+	got, err := pinboard.ImportMarkdown(strings.NewReader(doc))
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	want := []pinboard.Bookmark{
+		{URL: "https://go.dev/doc/", Title: "Go testing", Desc: "the basics",
+			Tags: []string{"tutorial", "go", "testing"}},
+		{URL: "https://b.com/", Tags: []string{"go", "testing"}},
+		{URL: "https://c.com/", Title: "C", Tags: []string{"misc"}},
+		{URL: "https://d.com/", Title: "D", Tags: []string{"misc"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bookmarks: got %+v want %+v", got, want)
+	}
+}
+
+func TestImport(t *testing.T) {
+	ts, f := startFakeAccounts(map[string][]fakePost{})
+	defer ts.Close()
+	pin := authed(t, "me:1")
+
+	bmarks := []pinboard.Bookmark{
+		{URL: "https://a.com/", Title: "A", Tags: []string{"go"}},
+		{URL: "not a url"},
+		{URL: "https://b.com/"},
+		{URL: "https://a.com/", Title: "A again"},
+	}
+	opt := pinboard.ImportOptions{DryRun: true, Tags: []string{"imported"}}
+
+	got := pin.Import(bmarks, opt)
+	if !reflect.DeepEqual(got.Added, []string{"https://a.com/", "https://b.com/"}) ||
+		!reflect.DeepEqual(got.Skipped, []string{"not a url", "https://a.com/"}) ||
+		len(got.Failed) != 0 {
+		t.Errorf("dry run: got %+v", got)
+	}
+	if len(f.adds) != 0 {
+		t.Errorf("dry run: got %v added want none", f.adds)
+	}
+
+	hooks := &recordingHooks{}
+	pin.SetHooks(hooks)
+	opt.DryRun = false
+	opt.Wait = 20 * time.Millisecond
+	start := time.Now()
+	got = pin.Import(bmarks, opt)
+	if len(got.Added) != 2 || len(got.Failed) != 0 {
+		t.Errorf("import: got %+v", got)
+	}
+	if d := time.Since(start); d < opt.Wait {
+		t.Errorf("import: took %v want at least %v", d, opt.Wait)
+	}
+	waits := 0
+	for _, c := range hooks.calls {
+		if c == "wait posts/add" {
+			waits++
+		}
+	}
+	if waits != 1 {
+		t.Errorf("waits: got %d want 1", waits)
+	}
+	posts := f.accounts["me:1"]
+	if len(posts) != 2 || posts[0].Tags != "go imported" || posts[1].Description != "https://b.com/" {
+		t.Errorf("posts: got %+v", posts)
+	}
+}
@@ -0,0 +1,133 @@
+package pinboard_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/umahmood/pinboard"
+)
+
+// startPageServer serves a HTML page at /page, a PDF at /pdf and a large page
+// at /large.
+func startPageServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<html><head><title>EFF</title>
+            <meta property="og:description" content="Defending your rights">
+            <link rel="canonical" href="/canonical"></head></html>`)
+		case "/pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			fmt.Fprint(w, "%PDF-1.4")
+		case "/large":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, strings.Repeat(" ", 1024)+"<title>too far</title>")
+		}
+	}))
+}
+
+func TestFetcherFetch(t *testing.T) {
+	ts := startPageServer()
+	defer ts.Close()
+
+	f := pinboard.Fetcher{}
+
+	got, err := f.Fetch(ts.URL + "/page")
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	want := pinboard.PageMeta{Title: "EFF",
+		Desc:      "Defending your rights",
+		Canonical: ts.URL + "/canonical",
+	}
+	if got != want {
+		t.Errorf("fetch: got %+v want %+v", got, want)
+	}
+}
+
+func TestFetcherFetchNotHTML(t *testing.T) {
+	ts := startPageServer()
+	defer ts.Close()
+
+	f := pinboard.Fetcher{}
+
+	_, err := f.Fetch(ts.URL + "/pdf")
+
+	wantError := "not an HTML page: application/pdf"
+	if err == nil || err.Error() != wantError {
+		t.Errorf("error: got %v want %s", err, wantError)
+	}
+}
+
+func TestFetcherFetchMaxSize(t *testing.T) {
+	ts := startPageServer()
+	defer ts.Close()
+
+	f := pinboard.Fetcher{MaxSize: 512}
+
+	got, err := f.Fetch(ts.URL + "/large")
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if got.Title != "" {
+		t.Errorf("title: got %s want \"\" (empty string)", got.Title)
+	}
+}
+
+func TestFetcherEnrich(t *testing.T) {
+	ts := startPageServer()
+	defer ts.Close()
+
+	f := pinboard.Fetcher{UseCanonical: true}
+	in := pinboard.Bookmark{URL: ts.URL + "/page", Title: "My title"}
+
+	got, err := f.Enrich(in)
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	want := pinboard.Bookmark{URL: ts.URL + "/canonical",
+		Title: "My title",
+		Desc:  "Defending your rights",
+	}
+	if err := compareBookmarks(got, want); err != nil {
+		t.Errorf("error: %v", err)
+	}
+}
+
+func TestAddEnriched(t *testing.T) {
+	ps := startPageServer()
+	defer ps.Close()
+	ts := startTestServer()
+	defer ts.Close()
+
+	pin := pinboard.New()
+	_, err := pin.Auth("mango:0123456789")
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	got, err := pin.AddEnriched(pinboard.Fetcher{},
+		pinboard.Bookmark{URL: ps.URL + "/page"}, true)
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if got != true {
+		t.Errorf("add enriched: got %v want true", got)
+	}
+
+	_, err = pin.AddEnriched(pinboard.Fetcher{},
+		pinboard.Bookmark{URL: ps.URL + "/pdf"}, false)
+
+	if err == nil {
+		t.Errorf("error: got nil want error")
+	}
+}
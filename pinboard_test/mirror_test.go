@@ -0,0 +1,130 @@
+package pinboard_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/umahmood/pinboard"
+)
+
+func TestMirrorOneWay(t *testing.T) {
+	ts, f := startFakeAccounts(map[string][]fakePost{
+		"team:1": {post("https://a.com", "A", "team"), post("https://b.com", "B", "other")},
+		"me:2":   {post("https://c.com", "C", "team")},
+	})
+	defer ts.Close()
+
+	m := pinboard.Mirror{Src: authed(t, "team:1"),
+		Dst:       authed(t, "me:2"),
+		Tags:      []string{"team"},
+		StatePath: filepath.Join(t.TempDir(), "sync.json"),
+	}
+
+	got, err := m.Sync()
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if len(got.ToDst) != 1 || got.ToDst[0] != "https://a.com" || len(got.ToSrc) != 0 {
+		t.Errorf("sync: got %+v want a.com copied to dst", got)
+	}
+	if p := f.accounts["me:2"][1]; p.Time != "2015-07-01T12:00:00Z" || p.Tags != "team" {
+		t.Errorf("copy: got %+v want creation time and tags kept", p)
+	}
+
+	// nothing changed, nothing is copied.
+	got, err = m.Sync()
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if len(got.ToDst) != 0 || len(got.ToSrc) != 0 {
+		t.Errorf("sync: got %+v want nothing copied", got)
+	}
+
+	// a change in the source is copied, a change in the destination is not.
+	f.accounts["team:1"][0].Description = "A2"
+	f.accounts["me:2"][0].Description = "C2"
+	got, err = m.Sync()
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if len(got.ToDst) != 1 || got.ToDst[0] != "https://a.com" || len(got.ToSrc) != 0 {
+		t.Errorf("sync: got %+v want a.com copied to dst", got)
+	}
+}
+
+func TestMirrorTwoWay(t *testing.T) {
+	ts, f := startFakeAccounts(map[string][]fakePost{
+		"team:1": {post("https://a.com", "A", "team")},
+		"me:2":   {post("https://c.com", "C", "team")},
+	})
+	defer ts.Close()
+
+	m := pinboard.Mirror{Src: authed(t, "team:1"),
+		Dst:       authed(t, "me:2"),
+		Tags:      []string{"team"},
+		Direction: pinboard.TwoWay,
+		StatePath: filepath.Join(t.TempDir(), "sync.json"),
+	}
+
+	got, err := m.Sync()
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if len(got.ToDst) != 1 || len(got.ToSrc) != 1 || got.ToSrc[0] != "https://c.com" {
+		t.Errorf("sync: got %+v want a.com and c.com copied", got)
+	}
+
+	f.accounts["me:2"][0].Description = "C2"
+	got, err = m.Sync()
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if len(got.ToSrc) != 1 || got.ToSrc[0] != "https://c.com" || len(got.ToDst) != 0 {
+		t.Errorf("sync: got %+v want c.com copied to src", got)
+	}
+	if f.accounts["team:1"][1].Description != "C2" {
+		t.Errorf("src: got %+v want C2", f.accounts["team:1"][1])
+	}
+}
+
+func TestMirrorConflict(t *testing.T) {
+	tests := []struct {
+		policy    pinboard.ConflictPolicy
+		wantTitle string
+		wantTags  string
+	}{
+		{pinboard.SourceWins, "Src", "team src"},
+		{pinboard.MergeTags, "Src", "team src dst"},
+		{pinboard.Newest, "Dst", "team dst"},
+	}
+	for _, test := range tests {
+		ts, f := startFakeAccounts(map[string][]fakePost{
+			"team:1": {post("https://a.com", "Src", "team src")},
+			"me:2":   {post("https://a.com", "Dst", "team dst")},
+		})
+		f.updated["me:2"] = "2015-07-02T00:00:00Z"
+
+		m := pinboard.Mirror{Src: authed(t, "team:1"),
+			Dst:       authed(t, "me:2"),
+			Tags:      []string{"team"},
+			Direction: pinboard.TwoWay,
+			Policy:    test.policy,
+		}
+
+		got, err := m.Sync()
+		if err != nil {
+			t.Errorf("error: got %v want nil", err)
+		}
+		if len(got.Conflicts) != 1 {
+			t.Errorf("conflicts: got %v want [https://a.com]", got.Conflicts)
+		}
+		for _, a := range []string{"team:1", "me:2"} {
+			p := f.accounts[a][0]
+			if p.Description != test.wantTitle || p.Tags != test.wantTags {
+				t.Errorf("policy %d %s: got %s %q want %s %q", test.policy, a,
+					p.Description, p.Tags, test.wantTitle, test.wantTags)
+			}
+		}
+		ts.Close()
+	}
+}
@@ -0,0 +1,114 @@
+package pinboard_test
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+// pagerAccount returns the posts of an account with n bookmarks, odd ones
+// tagged "odd".
+func pagerAccount(n int) map[string][]fakePost {
+	var posts []fakePost
+	for i := 0; i < n; i++ {
+		tags := "all"
+		if i%2 == 1 {
+			tags += " odd"
+		}
+		posts = append(posts, post(fmt.Sprintf("https://%d.com", i), "", tags))
+	}
+	return map[string][]fakePost{"me:1": posts}
+}
+
+func TestPager(t *testing.T) {
+	ts, f := startFakeAccounts(pagerAccount(5))
+	defer ts.Close()
+
+	pg := &pinboard.Pager{Pinboard: authed(t, "me:1"), PageSize: 2, Wait: 20 * time.Millisecond}
+	start := time.Now()
+	var sizes []int
+	for {
+		page, err := pg.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error: got %v want nil", err)
+		}
+		sizes = append(sizes, len(page))
+	}
+	if fmt.Sprint(sizes) != "[2 2 1]" {
+		t.Errorf("pages: got %v want [2 2 1]", sizes)
+	}
+	if pg.Offset != 5 {
+		t.Errorf("offset: got %d want 5", pg.Offset)
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("wait: got %v want at least 40ms between 3 pages", d)
+	}
+	f.mu.Lock()
+	if f.calls["/posts/all/"] != 3 {
+		t.Errorf("posts/all: got %d calls want 3", f.calls["/posts/all/"])
+	}
+	f.mu.Unlock()
+	if _, err := pg.Next(); err != io.EOF {
+		t.Errorf("error: got %v want EOF", err)
+	}
+}
+
+func TestPagerExactPages(t *testing.T) {
+	ts, _ := startFakeAccounts(pagerAccount(4))
+	defer ts.Close()
+
+	pg := &pinboard.Pager{Pinboard: authed(t, "me:1"), PageSize: 2, Wait: time.Millisecond}
+	all, err := pg.All()
+	if err != nil || len(all) != 4 || all[3].URL != "https://3.com" {
+		t.Errorf("all: got %d bookmarks, %v want 4", len(all), err)
+	}
+}
+
+func TestPagerFilter(t *testing.T) {
+	ts, _ := startFakeAccounts(pagerAccount(5))
+	defer ts.Close()
+
+	pg := &pinboard.Pager{Pinboard: authed(t, "me:1"),
+		Tags:     []string{"odd"},
+		PageSize: 1,
+		Wait:     time.Millisecond,
+	}
+	all, err := pg.All()
+	if err != nil || len(all) != 2 || all[0].URL != "https://1.com" || all[1].URL != "https://3.com" {
+		t.Errorf("all: got %+v, %v want 1.com and 3.com", all, err)
+	}
+}
+
+func TestPagerResume(t *testing.T) {
+	ts, _ := startFakeAccounts(pagerAccount(5))
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "pager.json")
+
+	pg := &pinboard.Pager{Pinboard: authed(t, "me:1"),
+		PageSize:  2,
+		Wait:      time.Millisecond,
+		StatePath: path,
+	}
+	pg.Next()
+	page, _ := pg.Next()
+	if page[0].URL != "https://2.com" {
+		t.Errorf("page: got %s want https://2.com", page[0].URL)
+	}
+	// crash while processing the second page, it is returned again.
+	pg = &pinboard.Pager{Pinboard: authed(t, "me:1"),
+		PageSize:  2,
+		Wait:      time.Millisecond,
+		StatePath: path,
+	}
+	all, err := pg.All()
+	if err != nil || len(all) != 3 || all[0].URL != "https://2.com" {
+		t.Errorf("all: got %+v, %v want 2.com to 4.com", all, err)
+	}
+}
@@ -23,6 +23,8 @@ func startTestServer() *httptest.Server {
 			switch p {
 			case "/user/api_token/":
 				fmt.Fprint(w, `{"result":"0123456789"}`)
+			case "/user/secret/":
+				fmt.Fprint(w, `{"result":"6493a84f72d86e7de130"}`)
 			case "/posts/update/":
 				fmt.Fprint(w, `{"update_time":"2015-07-02T17:03:45Z"}`)
 			case "/posts/add/", "/posts/delete/":
@@ -213,6 +215,57 @@ func TestAuthFail(t *testing.T) {
 	}
 }
 
+func TestSecret(t *testing.T) {
+	ts := startTestServer()
+	defer ts.Close()
+
+	want := "6493a84f72d86e7de130"
+
+	pin := pinboard.New()
+	pin.Auth("mango:0123456789")
+	got, err := pin.Secret()
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	if got != want {
+		t.Errorf("secret: got %s want %s", got, want)
+	}
+}
+
+func TestUser(t *testing.T) {
+	ts := startTestServer()
+	defer ts.Close()
+
+	want := pinboard.User{Name: "mango",
+		Token:  "mango:0123456789",
+		Secret: "6493a84f72d86e7de130",
+	}
+
+	pin := pinboard.New()
+	pin.Auth("mango:0123456789")
+	got, err := pin.User()
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	if got != want {
+		t.Errorf("user: got %+v want %+v", got, want)
+	}
+}
+
+func TestSecretUnauthed(t *testing.T) {
+	ts := startTestServer()
+	defer ts.Close()
+
+	pin := pinboard.New()
+	if _, err := pin.Secret(); err == nil {
+		t.Error("error: got nil want API not authorized")
+	}
+}
+
 func TestLastUpdate(t *testing.T) {
 	ts := startTestServer()
 	defer ts.Close()
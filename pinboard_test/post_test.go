@@ -0,0 +1,153 @@
+package pinboard_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/umahmood/pinboard"
+)
+
+// startURLLimitServer serves f, refusing URLs longer than 2048 bytes like many
+// servers do.
+func startURLLimitServer(f *fakeAccounts) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.URL.RequestURI()) > 2048 {
+			http.Error(w, "URI Too Long", http.StatusRequestURITooLong)
+			return
+		}
+		f.ServeHTTP(w, r)
+	}))
+	pinboard.BaseURL = ts.URL + "/%s/?%s"
+	return ts
+}
+
+// httpMethods returns the HTTP method of each request recorded by h.
+func httpMethods(h *recordingHooks) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var m []string
+	for _, r := range h.ends {
+		m = append(m, r.Method+" "+r.HTTPMethod)
+	}
+	return strings.Join(m, ", ")
+}
+
+func TestAddLongDescription(t *testing.T) {
+	accounts, f := startFakeAccounts(map[string][]fakePost{"me:1": nil})
+	accounts.Close()
+	ts := startURLLimitServer(f)
+	defer ts.Close()
+
+	pin := authed(t, "me:1")
+	h := &recordingHooks{}
+	pin.SetHooks(h)
+	desc := strings.Repeat("a long quote, ", 1000)
+	for _, b := range []pinboard.Bookmark{
+		{URL: "https://short.com", Title: "short"},
+		{URL: "https://long.com", Title: "long", Desc: desc},
+	} {
+		if ok, err := pin.Add(b); !ok || err != nil {
+			t.Fatalf("add %s: got %t, %v want true, nil", b.URL, ok, err)
+		}
+	}
+	if got, want := httpMethods(h), "posts/add GET, posts/add POST"; got != want {
+		t.Errorf("methods: got %s want %s", got, want)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if posts := f.accounts["me:1"]; len(posts) != 2 || posts[1].Extended != desc {
+		t.Errorf("posts: got %d, description of %d bytes want 2, %d bytes", len(posts),
+			len(posts[len(posts)-1].Extended), len(desc))
+	}
+
+	// without POST the server refuses the long URL.
+	pin.SetPostMode(pinboard.PostNever)
+	if _, err := pin.Add(pinboard.Bookmark{URL: "https://long.com", Desc: desc}); !isStatus(err,
+		http.StatusRequestURITooLong) {
+		t.Errorf("error: got %v want HTTP 414", err)
+	}
+}
+
+func TestPostModes(t *testing.T) {
+	ts, _ := startFakeAccounts(map[string][]fakePost{"me:1": {post("https://a.com", "A", "x")}})
+	defer ts.Close()
+
+	pin := authed(t, "me:1")
+	h := &recordingHooks{}
+	pin.SetHooks(h)
+	pin.SetPostMode(pinboard.PostAlways)
+	if ok, err := pin.Del("https://a.com"); !ok || err != nil {
+		t.Errorf("del: got %t, %v want true, nil", ok, err)
+	}
+	// only writes are POSTed.
+	if _, err := pin.LastUpdate(); err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if got, want := httpMethods(h), "posts/delete POST, posts/update GET"; got != want {
+		t.Errorf("methods: got %s want %s", got, want)
+	}
+}
+
+func TestPostFallback(t *testing.T) {
+	var mu sync.Mutex
+	var methods []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods = append(methods, r.Method)
+		mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		case r.URL.Path == "/user/api_token/":
+			fmt.Fprint(w, `{"result":"0123456789"}`)
+		default:
+			fmt.Fprint(w, `{"result_code":"done"}`)
+		}
+	}))
+	defer ts.Close()
+	pinboard.BaseURL = ts.URL + "/%s/?%s"
+
+	pin := authed(t, "me:1")
+	h := &recordingHooks{}
+	pin.SetHooks(h)
+	pin.SetPostMode(pinboard.PostAlways)
+	for _, u := range []string{"https://a.com", "https://b.com"} {
+		if ok, err := pin.Add(pinboard.Bookmark{URL: u}); !ok || err != nil {
+			t.Errorf("add: got %t, %v want true, nil", ok, err)
+		}
+	}
+	// the POST is refused once, later writes go straight to GET.
+	if got, want := fmt.Sprint(methods), "[GET POST GET GET]"; got != want {
+		t.Errorf("methods: got %s want %s", got, want)
+	}
+	if h.calls[2] != "retry posts/add 1" {
+		t.Errorf("calls: got %v want a retry after the refused POST", h.calls)
+	}
+}
+
+func TestRecorderPost(t *testing.T) {
+	ts, _ := startFakeAccounts(map[string][]fakePost{"mango:0123456789": nil})
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	pin := authed(t, "mango:0123456789")
+	pin.SetPostMode(pinboard.PostAlways)
+	pin.SetHTTPClient(&http.Client{Transport: &pinboard.Recorder{Path: path, Mode: pinboard.Record}})
+	if ok, err := pin.Add(pinboard.Bookmark{URL: "https://a.com", Title: "A"}); !ok || err != nil {
+		t.Fatalf("add: got %t, %v want true, nil", ok, err)
+	}
+	ts.Close()
+
+	pin.SetHTTPClient(&http.Client{Transport: &pinboard.Recorder{Path: path}})
+	if ok, err := pin.Add(pinboard.Bookmark{URL: "https://a.com", Title: "A"}); !ok || err != nil {
+		t.Errorf("add: got %t, %v want true, nil", ok, err)
+	}
+	// the form is matched too.
+	if _, err := pin.Add(pinboard.Bookmark{URL: "https://b.com", Title: "B"}); err == nil {
+		t.Error("add: got nil want no interaction")
+	}
+}
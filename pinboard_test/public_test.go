@@ -0,0 +1,167 @@
+package pinboard_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+// startFeedsServer serves Pinboard public feeds, requested paths are sent to
+// paths.
+func startFeedsServer(paths chan<- string) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		paths <- r.URL.RequestURI()
+		fmt.Fprint(w, `[{"u":"https:\/\/eff.org\/","d":"EFF","n":"rights",
+        "dt":"2015-07-02T07:56:40Z","a":"mango","t":["privacy","rights"]},
+        {"u":"https:\/\/golang.org\/","d":"Go","n":"",
+        "dt":"2015-07-01T12:00:00Z","a":"mango","t":[""]}]`)
+	}))
+	pinboard.FeedsURL = ts.URL + "/%s/%s"
+	return ts
+}
+
+func TestPublicFeed(t *testing.T) {
+	paths := make(chan string, 1)
+	ts := startFeedsServer(paths)
+	defer ts.Close()
+
+	tests := []struct {
+		in   pinboard.FeedQuery
+		want string
+	}{
+		{pinboard.FeedQuery{User: "mango"}, "/json/u:mango/"},
+		{pinboard.FeedQuery{User: "mango", Tags: []string{"go", "c++"}, Count: 10},
+			"/json/u:mango/t:go/t:c++/?count=10"},
+		{pinboard.FeedQuery{Tags: []string{"a b"}}, "/json/t:a%20b/"},
+		{pinboard.FeedQuery{User: "mango", Popular: true}, "/json/popular/"},
+		{pinboard.FeedQuery{}, "/json/recent/"},
+	}
+	for _, test := range tests {
+		got, err := pinboard.PublicFeed(test.in)
+		if err != nil {
+			t.Errorf("error: got %v want nil", err)
+		}
+		if p := <-paths; p != test.want {
+			t.Errorf("path: got %s want %s", p, test.want)
+		}
+		if len(got) != 2 {
+			t.Fatalf("len: got %d want 2", len(got))
+		}
+		want := pinboard.Bookmark{URL: "https://eff.org/",
+			Title:   "EFF",
+			Desc:    "rights",
+			Tags:    []string{"privacy", "rights"},
+			Created: time.Date(2015, 7, 2, 7, 56, 40, 0, time.UTC),
+			Shared:  true,
+		}
+		if err := compareBookmarks(got[0], want); err != nil {
+			t.Errorf("error: %v", err)
+		}
+		if len(got[1].Tags) != 0 {
+			t.Errorf("tags: got %v want []", got[1].Tags)
+		}
+	}
+}
+
+func TestParseFeedRSS(t *testing.T) {
+	in := `<?xml version="1.0" encoding="UTF-8"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+  xmlns="http://purl.org/rss/1.0/"
+  xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel rdf:about="http://pinboard.in">
+<title>Pinboard (mango)</title>
+</channel>
+<item rdf:about="https://eff.org/">
+<title>EFF</title>
+<dc:date>2015-07-02T07:56:40+00:00</dc:date>
+<link>https://eff.org/</link>
+<dc:creator>mango</dc:creator>
+<description><![CDATA[Defending <your> rights]]></description>
+<dc:subject>privacy rights</dc:subject>
+</item>
+</rdf:RDF>`
+
+	got, err := pinboard.ParseFeedRSS(strings.NewReader(in))
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("len: got %d want 1", len(got))
+	}
+	want := pinboard.Bookmark{URL: "https://eff.org/",
+		Title:   "EFF",
+		Desc:    "Defending <your> rights",
+		Tags:    []string{"privacy", "rights"},
+		Created: time.Date(2015, 7, 2, 7, 56, 40, 0, time.UTC),
+		Shared:  true,
+	}
+	if err := compareBookmarks(got[0], want); err != nil {
+		t.Errorf("error: %v", err)
+	}
+}
+
+func TestParseFeedRSSVersion2(t *testing.T) {
+	in := `<rss version="2.0"><channel><title>Links</title>
+<item><title>Go</title><link>https://golang.org/</link>
+<category>go</category><category>programming</category>
+<pubDate>Wed, 01 Jul 2015 12:00:00 +0000</pubDate></item>
+</channel></rss>`
+
+	got, err := pinboard.ParseFeedRSS(strings.NewReader(in))
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("len: got %d want 1", len(got))
+	}
+	want := pinboard.Bookmark{URL: "https://golang.org/",
+		Title:   "Go",
+		Tags:    []string{"go", "programming"},
+		Created: time.Date(2015, 7, 1, 12, 0, 0, 0, time.UTC),
+		Shared:  true,
+	}
+	if err := compareBookmarks(got[0], want); err != nil {
+		t.Errorf("error: %v", err)
+	}
+}
+
+func TestPrivateFeed(t *testing.T) {
+	accounts, _ := startFakeAccounts(nil)
+	defer accounts.Close()
+	paths := make(chan string, 1)
+	ts := startFeedsServer(paths)
+	defer ts.Close()
+
+	u, err := authed(t, "mango:1").User()
+	if err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if u.Name != "mango" || u.Secret != "secret-mango" {
+		t.Fatalf("user: got %+v want mango with secret-mango", u)
+	}
+
+	got, err := pinboard.PublicFeed(u.Feed("go"))
+	if err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	if p, want := <-paths, "/json/secret:secret-mango/u:mango/t:go/"; p != want {
+		t.Errorf("path: got %s want %s", p, want)
+	}
+	if len(got) != 2 || got[0].URL != "https://eff.org/" || got[0].Shared {
+		t.Errorf("feed: got %+v want 2 bookmarks not marked shared", got)
+	}
+
+	// a secret without a user selects no private feed.
+	pinboard.PublicFeed(pinboard.FeedQuery{Secret: "secret-mango", Tags: []string{"go"}})
+	if p, want := <-paths, "/json/t:go/"; p != want {
+		t.Errorf("path: got %s want %s", p, want)
+	}
+}
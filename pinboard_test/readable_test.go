@@ -0,0 +1,103 @@
+package pinboard_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+const articlePage = `<!DOCTYPE html>
+<html><head>
+<title>Go at scale | Example Blog</title>
+<meta property="og:title" content="Go at scale">
+<meta name="author" content="Jane Doe">
+<meta property="article:published_time" content="2015-07-13T10:00:00+01:00">
+<script>var tracking = "lots, of, commas, here, to, fool, scoring";</script>
+</head><body>
+<nav class="menu"><a href="/">Home</a> <a href="/about">About</a></nav>
+<div id="page">
+<div class="sidebar"><p>Subscribe to our newsletter, it is great, honestly, really.</p></div>
+<article class="post">
+<h1>Go at scale</h1>
+<p>Go was designed at Google to build large, reliable software, and it shows.
+<p>Its tooling, from gofmt to the race detector, keeps large codebases consistent.</p>
+<ul><li>Fast builds<li>Static binaries</ul>
+<p>A short line.</p>
+<div class="share"><a href="/share">Share this article on social networks</a></div>
+</article>
+<div class="comments"><p>Great post, thanks, I learned a lot, really, truly, wow.</p></div>
+</div>
+<footer><p>Copyright Example Blog, all rights reserved, 2015, forever.</p></footer>
+</body></html>`
+
+func TestExtractArticle(t *testing.T) {
+	got := pinboard.ExtractArticle([]byte(articlePage), "https://blog.example.com/go")
+	want := pinboard.Article{URL: "https://blog.example.com/go",
+		Title:     "Go at scale",
+		Byline:    "Jane Doe",
+		Published: time.Date(2015, 7, 13, 9, 0, 0, 0, time.UTC),
+		Text: "Go was designed at Google to build large, reliable software, and it shows.\n\n" +
+			"Its tooling, from gofmt to the race detector, keeps large codebases consistent.\n\n" +
+			"Fast builds\n\nStatic binaries\n\nA short line.",
+	}
+	if got != want {
+		t.Errorf("article: got %+v want %+v", got, want)
+	}
+}
+
+func TestExtractArticleFallbacks(t *testing.T) {
+	page := `<html><head><title>Notes</title></head><body>
+<div class="entry">
+<span class="byline">By John Smith</span> <time datetime="2015-07-13">July 13</time>
+<div>A page without paragraphs, written with line breaks, as old pages are.<br>
+Second line of text, also long enough to count as content.</div>
+</div></body></html>`
+	got := pinboard.ExtractArticle([]byte(page), "https://example.com/")
+	if got.Byline != "John Smith" {
+		t.Errorf("byline: got %q want John Smith", got.Byline)
+	}
+	if !got.Published.Equal(time.Date(2015, 7, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("published: got %v want 2015-07-13", got.Published)
+	}
+	want := "A page without paragraphs, written with line breaks, as old pages are. " +
+		"Second line of text, also long enough to count as content."
+	if got.Text != want {
+		t.Errorf("text: got %q want %q", got.Text, want)
+	}
+}
+
+func TestFetcherArticle(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, articlePage)
+	}))
+	defer ts.Close()
+
+	a, err := pinboard.Fetcher{}.Article(ts.URL)
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if a.URL != ts.URL || a.Title != "Go at scale" {
+		t.Errorf("article: got %+v", a)
+	}
+}
+
+func TestTextStore(t *testing.T) {
+	s := pinboard.TextStore{Dir: t.TempDir()}
+	b := pinboard.Bookmark{URL: "https://blog.example.com/go"}
+	if _, ok, err := s.Load(b); ok || err != nil {
+		t.Errorf("load: got %v, %v want false, nil", ok, err)
+	}
+	a := pinboard.ExtractArticle([]byte(articlePage), b.URL)
+	if err := s.Save(b, a); err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	got, ok, err := s.Load(b)
+	if !ok || err != nil || !got.Published.Equal(a.Published) || got.Text != a.Text {
+		t.Errorf("load: got %+v, %v, %v want %+v", got, ok, err, a)
+	}
+}
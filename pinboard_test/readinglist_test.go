@@ -0,0 +1,174 @@
+package pinboard_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+// startReadingServer serves two unread bookmarks and one read bookmark, every
+// request to add a bookmark is sent to added. Requests to posts/all are
+// counted in fetches.
+func startReadingServer(added chan<- url.Values, fetches *int32) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		switch r.URL.Path {
+		case "/user/api_token/":
+			fmt.Fprint(w, `{"result":"0123456789"}`)
+		case "/posts/update/":
+			fmt.Fprint(w, `{"update_time":"2015-07-03T12:00:00Z"}`)
+		case "/posts/all/":
+			if fetches != nil {
+				atomic.AddInt32(fetches, 1)
+			}
+			fmt.Fprint(w, `[{"href":"https://new.com","description":"new",
+            "extended":"","meta":"m1","hash":"h1","time":"2015-07-03T12:00:00Z",
+            "shared":"no","toread":"yes","tags":"go"},
+            {"href":"https://read.com","description":"read",
+            "extended":"","meta":"m2","hash":"h2","time":"2015-07-02T12:00:00Z",
+            "shared":"no","toread":"no","tags":"go"},
+            {"href":"https://old.com","description":"old",
+            "extended":"old extended","meta":"m3","hash":"h3",
+            "time":"2015-07-01T12:00:00Z","shared":"yes","toread":"yes",
+            "tags":"go web"}]`)
+		case "/posts/get/":
+			fmt.Fprint(w, `{"date":"2015-07-01T12:00:00Z","user":"mango",
+            "posts":[{"href":"https://old.com","description":"old",
+            "extended":"old extended","meta":"m3","hash":"h3",
+            "time":"2015-07-01T12:00:00Z","shared":"yes","toread":"yes",
+            "tags":"go web"}]}`)
+		case "/posts/add/":
+			added <- r.URL.Query()
+			fmt.Fprint(w, `{"result_code":"done"}`)
+		}
+	}))
+	pinboard.BaseURL = ts.URL + "/%s/?%s"
+	return ts
+}
+
+func TestReadingListUnread(t *testing.T) {
+	var fetches int32
+	ts := startReadingServer(nil, &fetches)
+	defer ts.Close()
+
+	pin := pinboard.New()
+	_, err := pin.Auth("mango:0123456789")
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	r, err := pinboard.NewReadingList(pin, "")
+	if err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+
+	got, err := r.Unread(nil, pinboard.OldestFirst)
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if len(got) != 2 || got[0].URL != "https://old.com" || got[1].URL != "https://new.com" {
+		t.Errorf("unread: got %v want [old new]", got)
+	}
+
+	got, err = r.Unread(nil, pinboard.NewestFirst)
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if len(got) != 2 || got[0].URL != "https://new.com" {
+		t.Errorf("unread: got %v want [new old]", got)
+	}
+
+	err = r.Snooze("https://new.com", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	b, err := r.Random(nil)
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if b.URL != "https://old.com" {
+		t.Errorf("random: got %s want https://old.com", b.URL)
+	}
+
+	got, err = r.Unread([]string{"Web"}, pinboard.OldestFirst)
+	if err != nil || len(got) != 1 || got[0].URL != "https://old.com" {
+		t.Errorf("unread web: got %v, %v want [old]", got, err)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("posts/all: got %d requests want 1", n)
+	}
+}
+
+func TestReadingListMarkRead(t *testing.T) {
+	added := make(chan url.Values, 1)
+	var fetches int32
+	ts := startReadingServer(added, &fetches)
+	defer ts.Close()
+
+	pin := pinboard.New()
+	_, err := pin.Auth("mango:0123456789")
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "reading.json")
+	r, err := pinboard.NewReadingList(pin, path)
+	if err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+
+	if _, err := r.Unread(nil, pinboard.OldestFirst); err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	err = r.MarkRead("https://old.com")
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	// the cached bookmark is marked read without fetching them all again.
+	unread, err := r.Unread(nil, pinboard.OldestFirst)
+	if err != nil || len(unread) != 1 || unread[0].URL != "https://new.com" {
+		t.Errorf("unread: got %v, %v want [new]", unread, err)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("posts/all: got %d requests want 1", n)
+	}
+
+	got := <-added
+	want := map[string]string{
+		"url":         "https://old.com",
+		"description": "old",
+		"extended":    "old extended",
+		"tags":        "go,web",
+		"dt":          "2015-07-01T12:00:00Z",
+		"replace":     "yes",
+		"shared":      "yes",
+		"toread":      "no",
+	}
+	for k, v := range want {
+		if got.Get(k) != v {
+			t.Errorf("%s: got %s want %s", k, got.Get(k), v)
+		}
+	}
+
+	// history is saved and loaded again.
+	r, err = pinboard.NewReadingList(pin, path)
+	if err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	h := r.History()
+	if len(h) != 1 || h[0].URL != "https://old.com" || !h[0].Read {
+		t.Errorf("history: got %v want 1 read event", h)
+	}
+	tp := r.Throughput()
+	if len(tp) != 1 || tp[0].Count != 1 {
+		t.Errorf("throughput: got %v want 1 read today", tp)
+	}
+}
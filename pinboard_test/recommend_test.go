@@ -0,0 +1,95 @@
+package pinboard_test
+
+import (
+	"testing"
+
+	"github.com/umahmood/pinboard"
+)
+
+// trainingBookmarks bookmarks used to train a recommender.
+var trainingBookmarks = []pinboard.Bookmark{
+	{URL: "https://golang.org/doc/effective_go.html",
+		Title: "Effective Go",
+		Tags:  []string{"go", "programming"}},
+	{URL: "https://golang.org/ref/spec",
+		Title: "The Go Programming Language Specification",
+		Tags:  []string{"go", "reference"}},
+	{URL: "https://blog.golang.org/concurrency-is-not-parallelism",
+		Title: "Concurrency is not parallelism",
+		Tags:  []string{"go", "concurrency"}},
+	{URL: "https://www.eff.org/issues/privacy",
+		Title: "Privacy",
+		Tags:  []string{"privacy", "rights"}},
+	{URL: "https://docs.python.org/3/library/asyncio.html",
+		Title: "asyncio - Asynchronous I/O",
+		Tags:  []string{"python", "concurrency", "programming"}},
+}
+
+func TestRecommendByDomain(t *testing.T) {
+	r := pinboard.NewRecommender(trainingBookmarks)
+
+	got := r.Recommend(pinboard.Bookmark{URL: "https://golang.org/pkg/"}, 1)
+
+	if len(got) != 1 || got[0].Name != "go" {
+		t.Errorf("recommend: got %v want [go]", got)
+	}
+}
+
+func TestRecommendByTitle(t *testing.T) {
+	r := pinboard.NewRecommender(trainingBookmarks)
+
+	got := r.Recommend(pinboard.Bookmark{URL: "https://example.com/",
+		Title: "Privacy tools"}, 0)
+
+	if len(got) != 2 || got[0].Name != "privacy" || got[1].Name != "rights" {
+		t.Errorf("recommend: got %v want [privacy rights]", got)
+	}
+}
+
+func TestRecommendByCooccurrence(t *testing.T) {
+	r := pinboard.NewRecommender(trainingBookmarks)
+
+	got := r.Recommend(pinboard.Bookmark{URL: "https://example.com/",
+		Tags: []string{"Python"}}, 0)
+
+	if len(got) != 2 {
+		t.Fatalf("len: got %v want 2", got)
+	}
+	for _, s := range got {
+		if s.Name != "concurrency" && s.Name != "programming" {
+			t.Errorf("recommend: got %v want [concurrency programming]", got)
+		}
+	}
+}
+
+func TestRecommendUnknown(t *testing.T) {
+	r := pinboard.NewRecommender(nil)
+
+	got := r.Recommend(pinboard.Bookmark{URL: "https://golang.org/",
+		Title: "Go"}, 5)
+
+	if len(got) != 0 {
+		t.Errorf("recommend: got %v want []", got)
+	}
+}
+
+func TestMergeSuggestions(t *testing.T) {
+	local := []pinboard.TagScore{{Name: "go", Score: 2}, {Name: "web", Score: 1}}
+	pop := pinboard.Popular{"golang", "Web"}
+	rec := pinboard.Recommended{"go"}
+
+	got := pinboard.MergeSuggestions(local, pop, rec, 0)
+
+	want := []pinboard.TagScore{{Name: "go", Score: 1.3},
+		{Name: "web", Score: 1}, {Name: "golang", Score: 0.5}}
+
+	if len(got) != len(want) {
+		t.Fatalf("len: got %v want %v", got, want)
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || got[i].Score-want[i].Score > 1e-9 ||
+			want[i].Score-got[i].Score > 1e-9 {
+			t.Errorf("merge: got %v want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package pinboard_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/umahmood/pinboard"
+)
+
+// searchIndex returns an index of three bookmarks.
+func searchIndex() *pinboard.Index {
+	idx := pinboard.NewIndex()
+	idx.Add(pinboard.Bookmark{URL: "https://a.com/", Title: "Go at scale", Tags: []string{"golang", "race-detector"}},
+		pinboard.Article{Text: "Go tooling keeps large codebases consistent. The race detector finds bugs."})
+	idx.Add(pinboard.Bookmark{URL: "https://b.com/", Title: "Rust ownership"},
+		pinboard.Article{Text: "Ownership rules prevent data races at compile time, unlike a race detector."})
+	idx.Add(pinboard.Bookmark{URL: "https://c.com/", Title: "Cooking pasta", Desc: "Salt the water."},
+		pinboard.Article{})
+	return idx
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx := searchIndex()
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"race detector", []string{"https://a.com/", "https://b.com/"}},
+		{"RACE compile", []string{"https://b.com/"}},
+		{"golang", []string{"https://a.com/"}},
+		{"salt water", []string{"https://c.com/"}},
+		{"race pasta", nil},
+		{"the", nil},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, r := range idx.Search(test.query, 0) {
+			got = append(got, r.URL)
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("search %q: got %v want %v", test.query, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("search %q: got %v want %v", test.query, got, test.want)
+			}
+		}
+	}
+	if got := idx.Search("race", 1); len(got) != 1 {
+		t.Errorf("search: got %d results want 1", len(got))
+	}
+}
+
+func TestIndexRemoveAndReplace(t *testing.T) {
+	idx := searchIndex()
+	idx.Remove(pinboard.Bookmark{URL: "https://a.com/"})
+	if got := idx.Search("detector", 0); len(got) != 1 || got[0].URL != "https://b.com/" {
+		t.Errorf("search: got %+v want b.com", got)
+	}
+	idx.Add(pinboard.Bookmark{URL: "https://c.com/", Title: "Cooking rice"}, pinboard.Article{})
+	if got := idx.Search("pasta", 0); len(got) != 0 {
+		t.Errorf("search: got %+v want nothing", got)
+	}
+	if idx.Len() != 2 {
+		t.Errorf("len: got %d want 2", idx.Len())
+	}
+}
+
+func TestIndexSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	if idx, err := pinboard.LoadIndex(path); err != nil || idx.Len() != 0 {
+		t.Errorf("load: got %v want empty index", err)
+	}
+	if err := searchIndex().Save(path); err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	idx, err := pinboard.LoadIndex(path)
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if got := idx.Search("golang", 0); len(got) != 1 || got[0].Title != "Go at scale" {
+		t.Errorf("search: got %+v", got)
+	}
+}
+
+func TestIndexLoadOldFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := searchIndex().Save(path); err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	// indexes saved by earlier versions don't keep the terms of documents.
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range raw["Docs"] {
+		delete(d, "Terms")
+	}
+	if data, err = json.Marshal(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := pinboard.LoadIndex(path)
+	if err != nil {
+		t.Fatalf("error: got %v want nil", err)
+	}
+	idx.Remove(pinboard.Bookmark{URL: "https://a.com/"})
+	if got := idx.Search("golang", 0); len(got) != 0 {
+		t.Errorf("search: got %+v want nothing", got)
+	}
+	if got := idx.Search("detector", 0); len(got) != 1 || got[0].URL != "https://b.com/" {
+		t.Errorf("search: got %+v want b.com", got)
+	}
+}
+
+func TestTextStoreIndexArticles(t *testing.T) {
+	s := pinboard.TextStore{Dir: t.TempDir()}
+	a := pinboard.Bookmark{URL: "https://a.com/", Title: "A"}
+	b := pinboard.Bookmark{URL: "https://b.com/", Title: "B"}
+	s.Save(a, pinboard.Article{URL: a.URL, Text: "inside the page"})
+
+	idx := pinboard.NewIndex()
+	if err := s.IndexArticles(idx, []pinboard.Bookmark{a, b}); err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if got := idx.Search("inside page", 0); len(got) != 1 || got[0].URL != a.URL {
+		t.Errorf("search: got %+v want a.com", got)
+	}
+	if idx.Len() != 2 {
+		t.Errorf("len: got %d want 2", idx.Len())
+	}
+}
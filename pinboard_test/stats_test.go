@@ -0,0 +1,139 @@
+package pinboard_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+// day returns midnight UTC on the given day of July 2015.
+func day(d int) time.Time {
+	return time.Date(2015, 7, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDailySeries(t *testing.T) {
+	in := []pinboard.Post{{Date: day(5), Count: 1}, {Date: day(2), Count: 2},
+		{Date: day(3), Count: 4}}
+
+	got := pinboard.DailySeries(in)
+
+	want := []pinboard.Post{{Date: day(2), Count: 2}, {Date: day(3), Count: 4},
+		{Date: day(4), Count: 0}, {Date: day(5), Count: 1}}
+
+	if len(got) != len(want) {
+		t.Fatalf("series: got %v want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("series: got %v want %v", got, want)
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	in := []pinboard.Post{
+		{Date: day(5), Count: 1}, // Sunday
+		{Date: day(6), Count: 2}, // Monday
+		{Date: day(7), Count: 3},
+		{Date: time.Date(2015, 8, 1, 0, 0, 0, 0, time.UTC), Count: 4},
+	}
+
+	tests := []struct {
+		period pinboard.Period
+		want   []pinboard.Post
+	}{
+		{pinboard.Week, []pinboard.Post{{Date: day(6).AddDate(0, 0, -7), Count: 1},
+			{Date: day(6), Count: 5},
+			{Date: time.Date(2015, 7, 27, 0, 0, 0, 0, time.UTC), Count: 4}}},
+		{pinboard.Month, []pinboard.Post{{Date: day(1), Count: 6},
+			{Date: time.Date(2015, 8, 1, 0, 0, 0, 0, time.UTC), Count: 4}}},
+		{pinboard.Year, []pinboard.Post{
+			{Date: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), Count: 10}}},
+	}
+	for _, test := range tests {
+		got := pinboard.Aggregate(in, test.period)
+		if len(got) != len(test.want) {
+			t.Errorf("aggregate %d: got %v want %v", test.period, got, test.want)
+			continue
+		}
+		for i := range test.want {
+			if got[i] != test.want[i] {
+				t.Errorf("aggregate %d: got %v want %v", test.period, got,
+					test.want)
+			}
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	in := []pinboard.Post{{Date: day(1), Count: 1}, {Date: day(2), Count: 5},
+		{Date: day(3), Count: 1}, {Date: day(5), Count: 2}, {Date: day(6), Count: 1}}
+
+	got := pinboard.Summarize(in, day(6).Add(13*time.Hour))
+
+	want := pinboard.Activity{Total: 10, Days: 6, ActiveDays: 5,
+		Average: 10.0 / 6, LongestStreak: 3, CurrentStreak: 2,
+		Busiest: pinboard.Post{Date: day(2), Count: 5}}
+
+	if got != want {
+		t.Errorf("summarize: got %+v want %+v", got, want)
+	}
+
+	got = pinboard.Summarize(in, day(8))
+
+	if got.CurrentStreak != 0 || got.Days != 8 {
+		t.Errorf("summarize: got %+v want current streak 0 over 8 days", got)
+	}
+}
+
+func TestWriteHeatmapSVG(t *testing.T) {
+	in := []pinboard.Post{{Date: day(1), Count: 4}, {Date: day(2), Count: 1}}
+
+	var buf bytes.Buffer
+	err := pinboard.WriteHeatmapSVG(&buf, in, day(4))
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	s := buf.String()
+	if !strings.HasPrefix(s, "<svg") || !strings.HasSuffix(s, "</svg>\n") {
+		t.Errorf("svg: got %s", s)
+	}
+	if n := strings.Count(s, "<rect"); n != 52*7+7 {
+		t.Errorf("rect count: got %d want %d", n, 52*7+7)
+	}
+	if !strings.Contains(s, `fill="#216e39"><title>2015-07-01: 4</title>`) {
+		t.Errorf("svg: missing busiest day")
+	}
+	if !strings.Contains(s, `fill="#9be9a8"><title>2015-07-02: 1</title>`) {
+		t.Errorf("svg: missing quiet day")
+	}
+}
+
+func TestWriteHeatmapText(t *testing.T) {
+	in := []pinboard.Post{{Date: day(1), Count: 4}, {Date: day(2), Count: 1}}
+
+	var buf bytes.Buffer
+	err := pinboard.WriteHeatmapText(&buf, in, day(4))
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("lines: got %d want 7", len(lines))
+	}
+	// July 1st 2015 was a Wednesday, July 4th a Saturday.
+	if !strings.HasPrefix(lines[3], "Wed ") || !strings.HasSuffix(lines[3], "█") {
+		t.Errorf("wednesday: got %s", lines[3])
+	}
+	if !strings.HasSuffix(lines[4], "░") {
+		t.Errorf("thursday: got %s", lines[4])
+	}
+	if n := len([]rune(lines[6])); n != 4+53 {
+		t.Errorf("saturday len: got %d want %d", n, 4+53)
+	}
+}
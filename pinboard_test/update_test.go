@@ -0,0 +1,155 @@
+package pinboard_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/umahmood/pinboard"
+)
+
+// startUpdateServer serves a single bookmark, its change detection signature
+// is taken from metas on each request to posts/get. Requests to add and delete
+// bookmarks are recorded in calls.
+func startUpdateServer(metas []string, calls *[]url.Values) *httptest.Server {
+	n := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		switch r.URL.Path {
+		case "/user/api_token/":
+			fmt.Fprint(w, `{"result":"0123456789"}`)
+		case "/posts/get/":
+			meta := metas[n%len(metas)]
+			n++
+			fmt.Fprintf(w, `{"date":"2015-07-01T12:00:00Z","user":"mango",
+            "posts":[{"href":"https://eff.org/","description":"EFF",
+            "extended":"digital rights","meta":"%s","hash":"h",
+            "time":"2015-07-01T12:00:00Z","shared":"yes","toread":"yes",
+            "tags":"privacy rights"}]}`, meta)
+		case "/posts/add/", "/posts/delete/":
+			q := r.URL.Query()
+			q.Set("path", r.URL.Path)
+			*calls = append(*calls, q)
+			fmt.Fprint(w, `{"result_code":"done"}`)
+		}
+	}))
+	pinboard.BaseURL = ts.URL + "/%s/?%s"
+	return ts
+}
+
+func TestUpdate(t *testing.T) {
+	var calls []url.Values
+	ts := startUpdateServer([]string{"m1"}, &calls)
+	defer ts.Close()
+
+	pin := pinboard.New()
+	_, err := pin.Auth("mango:0123456789")
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	err = pin.Update("https://eff.org/", func(b *pinboard.Bookmark) {
+		b.Title = "Electronic Frontier Foundation"
+	})
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("calls: got %v want 1", calls)
+	}
+	want := map[string]string{
+		"path":        "/posts/add/",
+		"url":         "https://eff.org/",
+		"description": "Electronic Frontier Foundation",
+		"extended":    "digital rights",
+		"tags":        "privacy,rights",
+		"dt":          "2015-07-01T12:00:00Z",
+		"replace":     "yes",
+		"shared":      "yes",
+		"toread":      "yes",
+	}
+	for k, v := range want {
+		if calls[0].Get(k) != v {
+			t.Errorf("%s: got %s want %s", k, calls[0].Get(k), v)
+		}
+	}
+}
+
+func TestUpdateMove(t *testing.T) {
+	var calls []url.Values
+	ts := startUpdateServer([]string{"m1"}, &calls)
+	defer ts.Close()
+
+	pin := pinboard.New()
+	_, err := pin.Auth("mango:0123456789")
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	err = pin.Update("https://eff.org/", func(b *pinboard.Bookmark) {
+		b.URL = "https://www.eff.org/"
+	})
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("calls: got %v want 2", calls)
+	}
+	if calls[0].Get("path") != "/posts/add/" || calls[0].Get("url") != "https://www.eff.org/" {
+		t.Errorf("add: got %v", calls[0])
+	}
+	if calls[1].Get("path") != "/posts/delete/" || calls[1].Get("url") != "https://eff.org/" {
+		t.Errorf("delete: got %v", calls[1])
+	}
+}
+
+func TestUpdateIf(t *testing.T) {
+	var calls []url.Values
+	ts := startUpdateServer([]string{"m1"}, &calls)
+	defer ts.Close()
+
+	pin := pinboard.New()
+	_, err := pin.Auth("mango:0123456789")
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	err = pin.UpdateIf("https://eff.org/", []byte("m1"), func(b *pinboard.Bookmark) {
+		b.ToRead = false
+	})
+
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+	if len(calls) != 1 || calls[0].Get("toread") != "no" {
+		t.Errorf("calls: got %v want an add", calls)
+	}
+}
+
+func TestUpdateConflict(t *testing.T) {
+	var calls []url.Values
+	ts := startUpdateServer([]string{"m2"}, &calls)
+	defer ts.Close()
+
+	pin := pinboard.New()
+	_, err := pin.Auth("mango:0123456789")
+	if err != nil {
+		t.Errorf("error: got %v want nil", err)
+	}
+
+	called := false
+	err = pin.UpdateIf("https://eff.org/", []byte("m1"), func(b *pinboard.Bookmark) {
+		called = true
+	})
+
+	if err != pinboard.ErrConflict {
+		t.Errorf("error: got %v want %v", err, pinboard.ErrConflict)
+	}
+	if called || len(calls) != 0 {
+		t.Errorf("calls: got %v, fn called %t want none", calls, called)
+	}
+}
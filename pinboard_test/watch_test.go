@@ -0,0 +1,164 @@
+package pinboard_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/umahmood/pinboard"
+)
+
+// waitFor waits for cond to hold, checked while holding f.mu.
+func waitFor(t *testing.T, f *fakeAccounts, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		f.mu.Lock()
+		ok := cond()
+		f.mu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting")
+}
+
+// receive returns the next n events.
+func receive(t *testing.T, events <-chan pinboard.Event, n int) []pinboard.Event {
+	var got []pinboard.Event
+	for len(got) < n {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("events: got %d want %d", len(got), n)
+		}
+	}
+	return got
+}
+
+func TestWatcher(t *testing.T) {
+	ts, f := startFakeAccounts(map[string][]fakePost{
+		"me:1": {post("https://a.com", "A", "go"), post("https://b.com", "B", "go")},
+	})
+	defer ts.Close()
+
+	secret := []byte("s3cret")
+	var (
+		mu    sync.Mutex
+		hooks []map[string]interface{}
+	)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !pinboard.VerifySignature(secret, body, r.Header.Get(pinboard.SignatureHeader)) {
+			t.Errorf("webhook: bad signature %q", r.Header.Get(pinboard.SignatureHeader))
+		}
+		var m map[string]interface{}
+		json.Unmarshal(body, &m)
+		mu.Lock()
+		hooks = append(hooks, m)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	w := pinboard.Watcher{Pinboard: authed(t, "me:1"),
+		Interval:     5 * time.Millisecond,
+		FullInterval: time.Millisecond,
+		Webhook:      hook.URL,
+		Secret:       secret,
+		OnError:      func(err error) { t.Errorf("error: got %v want nil", err) },
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := w.Watch(ctx)
+
+	waitFor(t, f, func() bool { return f.calls["/posts/all/"] == 1 })
+	calls := f.calls["/posts/update/"]
+	// polls without changes don't fetch bookmarks.
+	waitFor(t, f, func() bool { return f.calls["/posts/update/"] > calls+2 })
+	f.mu.Lock()
+	if f.calls["/posts/all/"] != 1 {
+		t.Errorf("posts/all: got %d calls want 1", f.calls["/posts/all/"])
+	}
+	f.accounts["me:1"][0].Description = "A2"
+	f.accounts["me:1"] = append(f.accounts["me:1"][:1], post("https://c.com", "C", ""))
+	f.updated["me:1"] = "2015-07-02T00:00:00Z"
+	f.mu.Unlock()
+
+	got := receive(t, events, 3)
+	want := []struct {
+		typ pinboard.EventType
+		url string
+	}{
+		{pinboard.Added, "https://c.com"},
+		{pinboard.Updated, "https://a.com"},
+		{pinboard.Deleted, "https://b.com"},
+	}
+	for i, e := range got {
+		if e.Type != want[i].typ || e.Bookmark.URL != want[i].url {
+			t.Errorf("event %d: got %v %s want %v %s", i, e.Type, e.Bookmark.URL,
+				want[i].typ, want[i].url)
+		}
+	}
+
+	cancel()
+	for range events {
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(hooks) != 3 {
+		t.Fatalf("webhooks: got %d want 3", len(hooks))
+	}
+	b := hooks[1]["bookmark"].(map[string]interface{})
+	if hooks[1]["type"] != "updated" || b["title"] != "A2" || b["url"] != "https://a.com" {
+		t.Errorf("webhook: got %v", hooks[1])
+	}
+}
+
+func TestWatcherRecentOnly(t *testing.T) {
+	ts, f := startFakeAccounts(map[string][]fakePost{
+		"me:1": {post("https://a.com", "A", "go"), post("https://b.com", "B", "go")},
+	})
+	defer ts.Close()
+
+	w := pinboard.Watcher{Pinboard: authed(t, "me:1"),
+		Interval:   5 * time.Millisecond,
+		RecentOnly: true,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := w.Watch(ctx)
+
+	waitFor(t, f, func() bool { return f.calls["/posts/recent/"] == 1 })
+	f.mu.Lock()
+	f.accounts["me:1"] = []fakePost{post("https://c.com", "C", "")}
+	f.updated["me:1"] = "2015-07-02T00:00:00Z"
+	f.mu.Unlock()
+
+	got := receive(t, events, 1)
+	if got[0].Type != pinboard.Added || got[0].Bookmark.URL != "https://c.com" {
+		t.Errorf("event: got %v %s want added https://c.com", got[0].Type, got[0].Bookmark.URL)
+	}
+	f.mu.Lock()
+	if f.calls["/posts/all/"] != 0 {
+		t.Errorf("posts/all: got %d calls want 0", f.calls["/posts/all/"])
+	}
+	f.mu.Unlock()
+	select {
+	case e := <-events:
+		t.Errorf("event: got %v %s want none, deletions are not reported", e.Type, e.Bookmark.URL)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"type":"added"}`)
+	sig := "sha256=5b1e5d6b0c1c37d8b2f8e1a4d06c5b9d4f0b2b2e9b6a4f1c9e4b7a4e2d3c1b0a"
+	if pinboard.VerifySignature([]byte("key"), body, sig) {
+		t.Errorf("verify: got true want false for a forged signature")
+	}
+}
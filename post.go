@@ -0,0 +1,95 @@
+package pinboard
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// PostMode is when write requests, which add, delete or rename bookmarks and
+// tags, are sent as form encoded POSTs instead of GETs.
+type PostMode int
+
+// Post modes.
+const (
+	// PostLong POSTs write requests whose URL would be longer than
+	// maxGetURL, e.g. bookmarks with long descriptions.
+	PostLong PostMode = iota
+	// PostAlways POSTs every write request.
+	PostAlways
+	// PostNever never POSTs.
+	PostNever
+)
+
+// maxGetURL is the length of the longest URL sent with GET by PostLong. Many
+// servers and proxies refuse longer URLs with HTTP 414 or 400.
+const maxGetURL = 2000
+
+// writeMethods are the API methods which write.
+var writeMethods = map[string]bool{
+	"posts/add":    true,
+	"posts/delete": true,
+	"tags/delete":  true,
+	"tags/rename":  true,
+}
+
+// SetPostMode sets when write requests are sent as form encoded POSTs, the
+// default is PostLong. If the server refuses a POST with HTTP 405 or 501 the
+// request is sent again as a GET, as are all write requests after it.
+func (p *Pinboard) SetPostMode(m PostMode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.postMode = m
+}
+
+// shouldPost reports whether the request for method to u is to be POSTed.
+func (p *Pinboard) shouldPost(method, u string) bool {
+	p.mu.RLock()
+	mode, rejected := p.postMode, p.postRejected
+	p.mu.RUnlock()
+	if !writeMethods[method] || rejected || mode == PostNever {
+		return false
+	}
+	return mode == PostAlways || len(u) > maxGetURL
+}
+
+// newRequest returns the request for u, the URL of an API method, as a GET or
+// as a form encoded POST of its query.
+func newRequest(u string, post bool) (*http.Request, error) {
+	if !post {
+		return http.NewRequest(http.MethodGet, u, nil)
+	}
+	base, query := u, ""
+	if i := strings.Index(u, "?"); i >= 0 {
+		base, query = u[:i], u[i+1:]
+	}
+	req, err := http.NewRequest(http.MethodPost, base, strings.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// exchange sends the request for method to u, POSTing it if it should be and
+// falling back to GET if the server refuses the POST.
+func (p *Pinboard) exchange(client *http.Client, hooks Hooks, method, u string) (io.ReadCloser, error) {
+	if !p.shouldPost(method, u) {
+		return send(client, hooks, method, u, false)
+	}
+	r, err := send(client, hooks, method, u, true)
+	var s *StatusError
+	if !errors.As(err, &s) || s.Code != http.StatusMethodNotAllowed &&
+		s.Code != http.StatusNotImplemented {
+		return r, err
+	}
+	p.mu.Lock()
+	p.postRejected = true
+	p.mu.Unlock()
+	if hooks != nil {
+		hooks.Retry(RequestInfo{Method: method, HTTPMethod: http.MethodPost, URL: redactURL(u),
+			Status: s.Code, Err: err}, 1)
+	}
+	return send(client, hooks, method, u, false)
+}
@@ -0,0 +1,170 @@
+package pinboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FeedsURL points to and specifies the format of the Pinboard public feeds,
+// the first verb is the feed format and the second the path of the feed.
+// ** Note ** as with BaseURL this is only a variable so the unit tests can
+// point it to a mock server. ** Do not ** change this URI.
+var FeedsURL = "https://feeds.pinboard.in/%s/%s"
+
+// FeedQuery selects one of the Pinboard public feeds. Public feeds need no API
+// token, they only contain shared bookmarks. With the users Secret the private
+// feed of User is selected instead, which contains private bookmarks too.
+type FeedQuery struct {
+	// User whose bookmarks are returned.
+	User string
+	// Secret is the RSS key of User, see Pinboard.Secret. Keep it out of logs,
+	// anyone with it can read the private bookmarks of User.
+	Secret string
+	// Tags bookmarks must have, of User if set otherwise of all users.
+	Tags []string
+	// Popular returns the popular bookmarks, User and Tags are ignored. If
+	// User, Tags and Popular are all empty the most recent bookmarks of all
+	// users are returned.
+	Popular bool
+	// Count number of bookmarks to return, the default is set by Pinboard
+	// max is 400.
+	Count int
+}
+
+// path returns the path of the feed selected by the query.
+func (q FeedQuery) path() string {
+	if q.Popular {
+		return "popular/"
+	}
+	var p string
+	if q.Secret != "" && q.User != "" {
+		p += "secret:" + url.PathEscape(q.Secret) + "/"
+	}
+	if q.User != "" {
+		p += "u:" + url.PathEscape(q.User) + "/"
+	}
+	for _, t := range q.Tags {
+		p += "t:" + url.PathEscape(t) + "/"
+	}
+	if p == "" {
+		return "recent/"
+	}
+	return p
+}
+
+// feedURL returns the URL of the feed in the given format.
+func (q FeedQuery) feedURL(format string) string {
+	u := fmt.Sprintf(FeedsURL, format, q.path())
+	if q.Count > 0 {
+		u += "?count=" + strconv.Itoa(q.Count)
+	}
+	return u
+}
+
+// PublicFeed fetches the public feed selected by q. Private feeds don't say
+// which bookmarks are shared, so Shared is false for their bookmarks.
+func PublicFeed(q FeedQuery) ([]Bookmark, error) {
+	data, err := do(q.feedURL("json"))
+	if err != nil {
+		return nil, err
+	}
+	bmarks, err := ParseFeedJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if q.Secret != "" && q.User != "" && !q.Popular {
+		for i := range bmarks {
+			bmarks[i].Shared = false
+		}
+	}
+	return bmarks, nil
+}
+
+// feedItem is a bookmark in a Pinboard JSON feed.
+type feedItem struct {
+	URL   string   `json:"u"`
+	Title string   `json:"d"`
+	Desc  string   `json:"n"`
+	Time  string   `json:"dt"`
+	Tags  []string `json:"t"`
+}
+
+// ParseFeedJSON parses a Pinboard JSON feed. Bookmarks in public feeds are
+// always shared.
+func ParseFeedJSON(r io.Reader) ([]Bookmark, error) {
+	var items []feedItem
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, err
+	}
+	var bmarks []Bookmark
+	for _, i := range items {
+		t, err := time.Parse(time.RFC3339, i.Time)
+		if err != nil {
+			t = time.Time{}
+		}
+		bmarks = append(bmarks, Bookmark{URL: i.URL,
+			Title:   i.Title,
+			Desc:    i.Desc,
+			Tags:    uniqueTags(i.Tags),
+			Created: t,
+			Shared:  true,
+		})
+	}
+	return bmarks, nil
+}
+
+// rssFeedItem is a bookmark in a Pinboard RSS feed, Pinboard uses RSS 1.0 with
+// Dublin Core dates and subjects.
+type rssFeedItem struct {
+	Title   string `xml:"title"`
+	Link    string `xml:"link"`
+	Desc    string `xml:"description"`
+	Date    string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Subject string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	PubDate string `xml:"pubDate"`
+	// RSS 2.0 tags.
+	Categories []string `xml:"category"`
+}
+
+// ParseFeedRSS parses a Pinboard RSS feed, RSS 2.0 feeds are also understood.
+// Bookmarks in public feeds are always shared.
+func ParseFeedRSS(r io.Reader) ([]Bookmark, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Items   []rssFeedItem `xml:"item"`
+		Channel []rssFeedItem `xml:"channel>item"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var bmarks []Bookmark
+	for _, i := range append(doc.Items, doc.Channel...) {
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(i.Date))
+		if err != nil {
+			t, err = time.Parse(time.RFC1123Z, strings.TrimSpace(i.PubDate))
+			if err != nil {
+				t = time.Time{}
+			}
+		}
+		tags := append(strings.Fields(i.Subject), i.Categories...)
+		bmarks = append(bmarks, Bookmark{URL: strings.TrimSpace(i.Link),
+			Title:   i.Title,
+			Desc:    i.Desc,
+			Tags:    uniqueTags(tags),
+			Created: t.UTC(),
+			Shared:  true,
+		})
+	}
+	return bmarks, nil
+}
@@ -0,0 +1,393 @@
+package pinboard
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Article represents the readable content of a web page.
+type Article struct {
+	URL       string
+	Title     string
+	Byline    string    // Author of the article, if the page names one.
+	Published time.Time // Time the article was published, if the page says.
+	Text      string    // Paragraphs of the article separated by blank lines.
+}
+
+// node is an element or text in a tree of HTML tokens.
+type node struct {
+	tag      string // "" for text.
+	attrs    map[string]string
+	text     string
+	parent   *node
+	children []*node
+}
+
+// voidElements are elements which never have content or an end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// blockElements are elements which close an open <p>.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"div": true, "dl": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "ul": true,
+}
+
+// buildTree builds a tree from HTML tokens. Like a browser it closes
+// elements left open, but only the common cases of <p> and <li>.
+func buildTree(tokens []htmlToken) *node {
+	root := &node{tag: "#document"}
+	stack := []*node{root}
+	top := func() *node { return stack[len(stack)-1] }
+	closeTo := func(tag string, stop map[string]bool) {
+		for i := len(stack) - 1; i > 0; i-- {
+			if stack[i].tag == tag {
+				stack = stack[:i]
+				return
+			}
+			if stop[stack[i].tag] {
+				return
+			}
+		}
+	}
+	for _, t := range tokens {
+		switch t.typ {
+		case htmlText:
+			n := &node{text: t.text, parent: top()}
+			top().children = append(top().children, n)
+		case htmlStartTag:
+			if blockElements[t.name] {
+				closeTo("p", map[string]bool{"div": true, "article": true,
+					"section": true, "td": true, "li": true, "blockquote": true})
+			}
+			if t.name == "li" {
+				closeTo("li", map[string]bool{"ul": true, "ol": true})
+			}
+			n := &node{tag: t.name, attrs: t.attrs, parent: top()}
+			top().children = append(top().children, n)
+			if !voidElements[t.name] {
+				stack = append(stack, n)
+			}
+		case htmlEndTag:
+			closeTo(t.name, nil)
+		}
+	}
+	return root
+}
+
+// walk calls fn with n and its descendants in document order, the children of
+// nodes for which fn returns false are skipped.
+func (n *node) walk(fn func(*node) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, c := range n.children {
+		c.walk(fn)
+	}
+}
+
+// textContent returns the text of n and its descendants with white space
+// collapsed.
+func (n *node) textContent() string {
+	var b strings.Builder
+	n.walk(func(c *node) bool {
+		if c.tag == "" {
+			b.WriteString(c.text)
+			b.WriteByte(' ')
+		}
+		return c.tag != "script" && c.tag != "style"
+	})
+	return collapseSpace(b.String())
+}
+
+// linkDensity returns the fraction of the text of n inside links.
+func (n *node) linkDensity() float64 {
+	total := len(n.textContent())
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	n.walk(func(c *node) bool {
+		if c.tag == "a" {
+			links += len(c.textContent())
+			return false
+		}
+		return true
+	})
+	return float64(links) / float64(total)
+}
+
+// hasBlockChild reports whether n contains block elements.
+func (n *node) hasBlockChild() bool {
+	found := false
+	for _, c := range n.children {
+		c.walk(func(d *node) bool {
+			if blockElements[d.tag] || d.tag == "li" {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// skippedElements never hold article content.
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "nav": true, "aside": true,
+	"footer": true, "form": true, "button": true, "iframe": true, "svg": true,
+	"select": true, "template": true, "head": true,
+}
+
+// unlikelyContent matches the class and id of elements which hold page
+// furniture rather than content.
+var unlikelyContent = regexp.MustCompile(`(?i)comment|sidebar|footer|menu|share|social|` +
+	`promo|related|advert|sponsor|cookie|subscribe|newsletter|popup|banner|breadcrumb|` +
+	`masthead|skip|widget|\bnav|\bads?\b`)
+
+// likelyContent matches the class and id of elements which hold content, they
+// are kept even if they also match unlikelyContent.
+var likelyContent = regexp.MustCompile(`(?i)article|body|content|entry|main|post|story|text`)
+
+// skipped reports whether n is page furniture.
+func (n *node) skipped() bool {
+	if skippedElements[n.tag] {
+		return true
+	}
+	if n.tag == "body" || n.tag == "article" || n.tag == "main" {
+		return false
+	}
+	s := n.attrs["class"] + " " + n.attrs["id"]
+	return unlikelyContent.MatchString(s) && !likelyContent.MatchString(s)
+}
+
+// isHeading reports whether tag is a heading.
+func isHeading(tag string) bool {
+	return len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6'
+}
+
+// paragraph reports whether n is a block of text in the article.
+func (n *node) paragraph() bool {
+	switch n.tag {
+	case "p", "pre", "blockquote", "li", "h1", "h2", "h3", "h4", "h5", "h6":
+		return true
+	case "div", "td":
+		// text laid out with <br> rather than paragraphs.
+		return !n.hasBlockChild()
+	}
+	return false
+}
+
+// ExtractArticle extracts the readable article from an HTML page fetched from
+// URL. Like Readability, paragraphs are scored by their length and commas and
+// the element holding the highest scoring paragraphs, less the text inside
+// links, is taken to be the article. Navigation, sidebars, comments and
+// similar page furniture are dropped.
+func ExtractArticle(page []byte, URL string) Article {
+	doc := string(page)
+	m := parsePageMeta(doc)
+	tokens := tokenizeHTML(doc)
+	a := Article{URL: URL,
+		Title:     m.Title,
+		Byline:    byline(tokens),
+		Published: published(tokens),
+	}
+	root := buildTree(tokens)
+
+	// score the parents and grandparents of every paragraph.
+	scores := make(map[*node]float64)
+	var candidates []*node
+	root.walk(func(n *node) bool {
+		if n.skipped() {
+			return false
+		}
+		if !n.paragraph() || n.tag == "li" || isHeading(n.tag) {
+			return true
+		}
+		text := n.textContent()
+		if len(text) < 25 {
+			return true
+		}
+		length := len(text) / 100
+		if length > 3 {
+			length = 3
+		}
+		s := float64(1 + strings.Count(text, ",") + length)
+		for i, p := 0, n.parent; i < 2 && p != nil; i, p = i+1, p.parent {
+			if _, ok := scores[p]; !ok {
+				candidates = append(candidates, p)
+			}
+			scores[p] += s / float64(i+1)
+		}
+		return true
+	})
+	var best *node
+	bestScore := 0.0
+	for _, c := range candidates {
+		s := scores[c] * (1 - c.linkDensity())
+		if c.tag == "article" || c.tag == "main" {
+			s *= 1.25
+		}
+		if s > bestScore {
+			best, bestScore = c, s
+		}
+	}
+	if best == nil {
+		best = root
+	}
+
+	var paras []string
+	best.walk(func(n *node) bool {
+		if n != best && n.skipped() {
+			return false
+		}
+		if !n.paragraph() {
+			return true
+		}
+		text := n.textContent()
+		if text != "" && (isHeading(n.tag) || n.linkDensity() < 0.5) {
+			paras = append(paras, text)
+		}
+		return false
+	})
+	if a.Title != "" && len(paras) > 0 && paras[0] == a.Title {
+		paras = paras[1:]
+	}
+	a.Text = strings.Join(paras, "\n\n")
+	return a
+}
+
+// byline returns the author named by a page.
+func byline(tokens []htmlToken) string {
+	for i, t := range tokens {
+		if t.typ != htmlStartTag {
+			continue
+		}
+		switch {
+		case t.name == "meta" && (strings.EqualFold(t.attrs["name"], "author") ||
+			strings.EqualFold(t.attrs["property"], "article:author")):
+			if c := collapseSpace(t.attrs["content"]); c != "" && !strings.Contains(c, "://") {
+				return c
+			}
+		case t.attrs["rel"] == "author" || t.attrs["itemprop"] == "author" ||
+			strings.Contains(strings.ToLower(t.attrs["class"]), "byline"):
+			// the text up to the end of the element.
+			var text string
+			for _, n := range tokens[i+1:] {
+				if n.typ == htmlEndTag && n.name == t.name {
+					break
+				}
+				if n.typ == htmlText {
+					text += n.text + " "
+				}
+			}
+			text = strings.TrimSpace(strings.TrimPrefix(collapseSpace(text), "By "))
+			if text = strings.TrimPrefix(text, "by "); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// dateLayouts are the layouts published dates are parsed with.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+}
+
+// parseDate parses a date in one of dateLayouts.
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, l := range dateLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// published returns the time a page says it was published.
+func published(tokens []htmlToken) time.Time {
+	var first time.Time
+	for _, t := range tokens {
+		if t.typ != htmlStartTag {
+			continue
+		}
+		var s string
+		switch {
+		case t.name == "meta":
+			switch strings.ToLower(firstNonEmpty(t.attrs["property"], t.attrs["name"],
+				t.attrs["itemprop"])) {
+			case "article:published_time", "datepublished", "date", "pubdate",
+				"publish-date", "dc.date.issued", "dc.date":
+				s = t.attrs["content"]
+			}
+		case t.attrs["itemprop"] == "datePublished":
+			s = firstNonEmpty(t.attrs["datetime"], t.attrs["content"])
+		case t.name == "time" && first.IsZero():
+			if d, ok := parseDate(t.attrs["datetime"]); ok {
+				first = d
+			}
+		}
+		if d, ok := parseDate(s); ok {
+			return d
+		}
+	}
+	return first
+}
+
+// Article fetches the page at URL and extracts its readable article.
+func (f Fetcher) Article(URL string) (Article, error) {
+	body, err := f.get(URL)
+	if err != nil {
+		return Article{}, err
+	}
+	return ExtractArticle(body, URL), nil
+}
+
+// TextStore stores the articles of bookmarked pages under Dir, by bookmark Hash
+// in the same layout as Archiver, so an article sits next to the snapshot of
+// its page when both share a directory.
+type TextStore struct {
+	Dir string
+}
+
+// path returns the file the article of a bookmark is stored in.
+func (s TextStore) path(b Bookmark) string {
+	key := archiveKey(b)
+	return filepath.Join(s.Dir, key[:2], key, "article.json")
+}
+
+// Save stores the article of a bookmark, replacing any earlier one.
+func (s TextStore) Save(b Bookmark, a Article) error {
+	if err := os.MkdirAll(filepath.Dir(s.path(b)), 0755); err != nil {
+		return err
+	}
+	return saveJSON(s.path(b), a)
+}
+
+// Load returns the stored article of a bookmark, false if there is none.
+func (s TextStore) Load(b Bookmark) (Article, bool, error) {
+	var a Article
+	if err := loadJSON(s.path(b), &a); err != nil {
+		return Article{}, false, err
+	}
+	return a, a.URL != "", nil
+}
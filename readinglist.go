@@ -0,0 +1,231 @@
+package pinboard
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReadOrder is the order unread bookmarks are returned in.
+type ReadOrder int
+
+// Orders unread bookmarks can be returned in.
+const (
+	OldestFirst ReadOrder = iota
+	NewestFirst
+)
+
+// ReadEvent records a bookmark being marked as read or unread.
+type ReadEvent struct {
+	URL  string
+	Read bool
+	Time time.Time
+}
+
+// readingState is the state of a reading list saved between runs.
+type readingState struct {
+	History []ReadEvent
+	Snoozed map[string]time.Time
+}
+
+// ReadingList is a reading queue built on the ToRead flag of bookmarks. The
+// bookmarks themselves stay in Pinboard, the history of read and unread
+// bookmarks and snoozed bookmarks are kept locally. The users bookmarks are
+// cached and only fetched again once LastUpdate moves, as posts/all may only
+// be requested once every five minutes.
+type ReadingList struct {
+	pin   *Pinboard
+	path  string
+	state readingState
+	rand  *rand.Rand
+
+	all  []Bookmark // cache of the users bookmarks.
+	seen time.Time  // LastUpdate of all.
+}
+
+// NewReadingList returns a reading list for the users bookmarks. Local state is
+// loaded from and saved to the file at path, if path is empty state is only
+// kept in memory.
+func NewReadingList(p *Pinboard, path string) (*ReadingList, error) {
+	r := &ReadingList{pin: p,
+		path:  path,
+		state: readingState{Snoozed: make(map[string]time.Time)},
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if path == "" {
+		return r, nil
+	}
+	if err := loadJSON(path, &r.state); err != nil {
+		return nil, err
+	}
+	if r.state.Snoozed == nil {
+		r.state.Snoozed = make(map[string]time.Time)
+	}
+	return r, nil
+}
+
+// save writes the local state to disk.
+func (r *ReadingList) save() error {
+	if r.path == "" {
+		return nil
+	}
+	return saveJSON(r.path, r.state)
+}
+
+// bookmarks returns the users bookmarks, from the cache unless LastUpdate has
+// moved.
+func (r *ReadingList) bookmarks() ([]Bookmark, error) {
+	last, err := r.pin.LastUpdate()
+	if err != nil {
+		return nil, err
+	}
+	if r.all != nil && !last.After(r.seen) {
+		return r.all, nil
+	}
+	all, err := r.pin.Bookmarks(nil, 0, 0, time.Time{}, time.Time{}, false)
+	if err != nil {
+		return nil, err
+	}
+	if all == nil {
+		all = []Bookmark{}
+	}
+	r.all, r.seen = all, last
+	return all, nil
+}
+
+// hasTags reports whether b is tagged with all of tags.
+func hasTags(b Bookmark, tags []string) bool {
+	for _, t := range tags {
+		found := false
+		for _, bt := range b.Tags {
+			if strings.EqualFold(bt, t) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Unread returns the unread bookmarks tagged with all of tags, tags may be nil.
+// Snoozed bookmarks are left out until their snooze expires.
+func (r *ReadingList) Unread(tags []string, order ReadOrder) ([]Bookmark, error) {
+	all, err := r.bookmarks()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var unread []Bookmark
+	for _, b := range all {
+		if !b.ToRead || !hasTags(b, tags) {
+			continue
+		}
+		if until, ok := r.state.Snoozed[b.URL]; ok && now.Before(until) {
+			continue
+		}
+		unread = append(unread, b)
+	}
+	sort.SliceStable(unread, func(i, j int) bool {
+		if order == NewestFirst {
+			return unread[i].Created.After(unread[j].Created)
+		}
+		return unread[i].Created.Before(unread[j].Created)
+	})
+	return unread, nil
+}
+
+// Random returns a random unread bookmark tagged with all of tags. Returns
+// ErrNotFound if there are no unread bookmarks.
+func (r *ReadingList) Random(tags []string) (Bookmark, error) {
+	unread, err := r.Unread(tags, OldestFirst)
+	if err != nil {
+		return Bookmark{}, err
+	}
+	if len(unread) == 0 {
+		return Bookmark{}, ErrNotFound
+	}
+	return unread[r.rand.Intn(len(unread))], nil
+}
+
+// setToRead sets the ToRead flag of the bookmark for URL to toRead and records
+// it in the history.
+func (r *ReadingList) setToRead(URL string, toRead bool) error {
+	err := r.pin.Update(URL, func(b *Bookmark) {
+		b.ToRead = toRead
+	})
+	if err != nil {
+		return err
+	}
+	r.cached(URL, toRead)
+	delete(r.state.Snoozed, URL)
+	r.state.History = append(r.state.History, ReadEvent{URL: URL,
+		Read: !toRead,
+		Time: time.Now().UTC(),
+	})
+	return r.save()
+}
+
+// cached sets the ToRead flag of the cached bookmark for URL, so the change
+// made by the reading list itself doesn't need the bookmarks fetched again.
+// The cache is dropped if that can't be done.
+func (r *ReadingList) cached(URL string, toRead bool) {
+	last, err := r.pin.LastUpdate()
+	if err != nil {
+		r.all = nil
+		return
+	}
+	norm, err := r.pin.normalizeURL(URL)
+	if err != nil {
+		r.all = nil
+		return
+	}
+	for i, b := range r.all {
+		if b.URL == URL || b.URL == norm {
+			r.all[i].ToRead = toRead
+			r.seen = last
+			return
+		}
+	}
+	r.all = nil
+}
+
+// MarkRead marks the bookmark for URL as read.
+func (r *ReadingList) MarkRead(URL string) error {
+	return r.setToRead(URL, false)
+}
+
+// MarkUnread puts the bookmark for URL back on the reading list.
+func (r *ReadingList) MarkUnread(URL string) error {
+	return r.setToRead(URL, true)
+}
+
+// Snooze hides the bookmark for URL from Unread and Random until the given
+// time.
+func (r *ReadingList) Snooze(URL string, until time.Time) error {
+	r.state.Snoozed[URL] = until
+	return r.save()
+}
+
+// History returns every bookmark marked as read or unread through the reading
+// list, oldest first.
+func (r *ReadingList) History() []ReadEvent {
+	h := make([]ReadEvent, len(r.state.History))
+	copy(h, r.state.History)
+	return h
+}
+
+// Throughput returns the number of bookmarks marked as read on each day, the
+// result can be passed to Summarize or Aggregate.
+func (r *ReadingList) Throughput() []Post {
+	var posts []Post
+	for _, e := range r.state.History {
+		if e.Read {
+			posts = append(posts, Post{Date: truncateDay(e.Time), Count: 1})
+		}
+	}
+	return Aggregate(posts, Day)
+}
@@ -0,0 +1,236 @@
+package pinboard
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// TagScore represents a recommended tag, a higher score is a better match.
+type TagScore struct {
+	Name  string
+	Score float64
+}
+
+// Weights of each signal when scoring recommended tags.
+const (
+	domainWeight = 1.0
+	titleWeight  = 0.8
+	coocWeight   = 0.6
+
+	popularWeight     = 0.5
+	recommendedWeight = 0.3
+)
+
+// stopWords are common title words which carry no meaning for tagging.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true,
+	"how": true, "what": true, "why": true, "your": true, "you": true,
+	"are": true, "this": true, "that": true, "into": true, "about": true,
+	"www": true, "com": true, "org": true, "http": true, "https": true,
+}
+
+// Recommender recommends tags for bookmarks, learned from the tags the user
+// has given their own bookmarks. It complements Suggest, which returns nothing
+// useful for new or private URLs.
+type Recommender struct {
+	tags    map[string]int            // tag -> # of bookmarks.
+	cooc    map[string]map[string]int // tag -> tag -> # of bookmarks with both.
+	domains map[string]int            // domain -> # of bookmarks.
+	byHost  map[string]map[string]int // domain -> tag -> # of bookmarks.
+	words   map[string]int            // title word -> # of bookmarks.
+	byWord  map[string]map[string]int // title word -> tag -> # of bookmarks.
+}
+
+// NewRecommender returns a recommender trained on bmarks, typically all the
+// bookmarks returned by Bookmarks().
+func NewRecommender(bmarks []Bookmark) *Recommender {
+	r := &Recommender{
+		tags:    make(map[string]int),
+		cooc:    make(map[string]map[string]int),
+		domains: make(map[string]int),
+		byHost:  make(map[string]map[string]int),
+		words:   make(map[string]int),
+		byWord:  make(map[string]map[string]int),
+	}
+	for _, b := range bmarks {
+		r.Train(b)
+	}
+	return r
+}
+
+// Train adds a single bookmark to the recommender.
+func (r *Recommender) Train(b Bookmark) {
+	tags := tagSet(b.Tags)
+	if len(tags) == 0 {
+		return
+	}
+	for _, t := range tags {
+		r.tags[t]++
+		for _, s := range tags {
+			if s != t {
+				inc(r.cooc, t, s)
+			}
+		}
+	}
+	if d := domainOf(b.URL); d != "" {
+		r.domains[d]++
+		for _, t := range tags {
+			inc(r.byHost, d, t)
+		}
+	}
+	for _, w := range titleWords(b.Title) {
+		r.words[w]++
+		for _, t := range tags {
+			inc(r.byWord, w, t)
+		}
+	}
+}
+
+// Recommend returns up to n tags for the bookmark ranked by score, n <= 0
+// returns all tags. Tags the bookmark already has are used as a signal and
+// never recommended. Scores combine how often a tag is used on the same
+// domain, with the same title words and alongside the bookmarks tags.
+func (r *Recommender) Recommend(b Bookmark, n int) []TagScore {
+	scores := make(map[string]float64)
+	have := make(map[string]bool)
+	tags := tagSet(b.Tags)
+	for _, t := range tags {
+		have[t] = true
+	}
+
+	if d := domainOf(b.URL); r.domains[d] > 0 {
+		for t, c := range r.byHost[d] {
+			scores[t] += domainWeight * float64(c) / float64(r.domains[d])
+		}
+	}
+
+	words := titleWords(b.Title)
+	for _, w := range words {
+		if r.words[w] > 0 {
+			for t, c := range r.byWord[w] {
+				scores[t] += titleWeight * float64(c) / float64(r.words[w]) /
+					float64(len(words))
+			}
+		}
+		// a title word which is also a tag is a strong hint.
+		if r.tags[w] > 0 {
+			scores[w] += titleWeight / float64(len(words))
+		}
+	}
+
+	for _, s := range tags {
+		if r.tags[s] == 0 {
+			continue
+		}
+		for t, c := range r.cooc[s] {
+			scores[t] += coocWeight * float64(c) / float64(r.tags[s]) /
+				float64(len(tags))
+		}
+	}
+
+	var ranked []TagScore
+	for t, s := range scores {
+		if !have[t] {
+			ranked = append(ranked, TagScore{Name: t, Score: s})
+		}
+	}
+	return topScores(ranked, n)
+}
+
+// MergeSuggestions merges local recommendations with the popular and
+// recommended tags returned by Suggest, returning up to n tags ranked by
+// score, n <= 0 returns all tags. Local scores are scaled to [0,1] and tags
+// suggested by the Pinboard service score a fixed bonus on top.
+func MergeSuggestions(local []TagScore, pop Popular, rec Recommended, n int) []TagScore {
+	var max float64
+	for _, s := range local {
+		if s.Score > max {
+			max = s.Score
+		}
+	}
+	scores := make(map[string]float64)
+	for _, s := range local {
+		if max > 0 {
+			scores[strings.ToLower(s.Name)] += s.Score / max
+		}
+	}
+	for _, t := range pop {
+		scores[strings.ToLower(t)] += popularWeight
+	}
+	for _, t := range rec {
+		scores[strings.ToLower(t)] += recommendedWeight
+	}
+	var ranked []TagScore
+	for t, s := range scores {
+		if t != "" {
+			ranked = append(ranked, TagScore{Name: t, Score: s})
+		}
+	}
+	return topScores(ranked, n)
+}
+
+// topScores sorts tags by descending score, ties by name, and returns the top
+// n, n <= 0 returns all tags.
+func topScores(s []TagScore, n int) []TagScore {
+	sort.Slice(s, func(i, j int) bool {
+		if s[i].Score != s[j].Score {
+			return s[i].Score > s[j].Score
+		}
+		return s[i].Name < s[j].Name
+	})
+	if n > 0 && len(s) > n {
+		s = s[:n]
+	}
+	return s
+}
+
+// inc increments m[k][v], creating the inner map if needed.
+func inc(m map[string]map[string]int, k, v string) {
+	if m[k] == nil {
+		m[k] = make(map[string]int)
+	}
+	m[k][v]++
+}
+
+// tagSet returns the lower cased, non empty and unique tags in tags.
+func tagSet(tags []string) []string {
+	seen := make(map[string]bool)
+	var s []string
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && !seen[t] {
+			seen[t] = true
+			s = append(s, t)
+		}
+	}
+	return s
+}
+
+// domainOf returns the lower cased host of URL without a "www." prefix.
+func domainOf(URL string) string {
+	u, err := url.Parse(URL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// titleWords returns the unique, lower cased words in title which are at least
+// three characters long and not stop words.
+func titleWords(title string) []string {
+	f := strings.FieldsFunc(strings.ToLower(title), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsNumber(c)
+	})
+	seen := make(map[string]bool)
+	var words []string
+	for _, w := range f {
+		if len(w) < 3 || stopWords[w] || seen[w] {
+			continue
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	return words
+}
@@ -0,0 +1,226 @@
+package pinboard
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchResult is a bookmark matching a search.
+type SearchResult struct {
+	URL   string
+	Title string
+	Score float64
+}
+
+// indexDoc is a document in an Index.
+type indexDoc struct {
+	URL    string
+	Title  string
+	Length int      // Number of terms.
+	Terms  []string // Distinct terms, so removing the document is cheap.
+}
+
+// indexData is the part of an Index saved to disk.
+type indexData struct {
+	Docs  map[string]indexDoc       // key -> document.
+	Terms map[string]map[string]int // term -> key -> # of occurrences.
+}
+
+// Index is a local full text index of bookmarks and the articles of their
+// pages, so bookmarks can be searched by what the pages say and not just by
+// their titles and descriptions. Documents are keyed by bookmark Hash and
+// ranked with BM25.
+type Index struct {
+	data indexData
+}
+
+// indexTitleWeight is how many times more a term in a title or tag counts
+// than one in the text.
+const indexTitleWeight = 3
+
+// NewIndex returns an empty index.
+func NewIndex() *Index {
+	return &Index{data: indexData{Docs: make(map[string]indexDoc),
+		Terms: make(map[string]map[string]int),
+	}}
+}
+
+// LoadIndex loads an index saved with Save. A missing file gives an empty
+// index.
+func LoadIndex(path string) (*Index, error) {
+	idx := NewIndex()
+	if err := loadJSON(path, &idx.data); err != nil {
+		return nil, err
+	}
+	if idx.data.Docs == nil {
+		idx.data.Docs = make(map[string]indexDoc)
+	}
+	if idx.data.Terms == nil {
+		idx.data.Terms = make(map[string]map[string]int)
+	}
+	// indexes saved before documents kept their terms.
+	missing := make(map[string]bool)
+	for key, d := range idx.data.Docs {
+		if d.Terms == nil {
+			missing[key] = true
+		}
+	}
+	if len(missing) > 0 {
+		for t, docs := range idx.data.Terms {
+			for key := range docs {
+				if missing[key] {
+					d := idx.data.Docs[key]
+					d.Terms = append(d.Terms, t)
+					idx.data.Docs[key] = d
+				}
+			}
+		}
+	}
+	return idx, nil
+}
+
+// Save writes the index to the file at path.
+func (idx *Index) Save(path string) error {
+	return saveJSON(path, idx.data)
+}
+
+// Len returns the number of documents in the index.
+func (idx *Index) Len() int {
+	return len(idx.data.Docs)
+}
+
+// terms splits text into lower case search terms, leaving out stop words and
+// single characters.
+func terms(text string) []string {
+	f := strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsNumber(c)
+	})
+	var t []string
+	for _, w := range f {
+		if len([]rune(w)) > 1 && !stopWords[w] {
+			t = append(t, w)
+		}
+	}
+	return t
+}
+
+// Add indexes a bookmark and the article of its page, a may be the zero
+// Article to index only the bookmark. A bookmark already in the index is
+// replaced.
+func (idx *Index) Add(b Bookmark, a Article) {
+	key := archiveKey(b)
+	idx.Remove(b)
+	counts := make(map[string]int)
+	for _, t := range terms(firstNonEmpty(b.Title, a.Title) + " " +
+		strings.Join(b.Tags, " ")) {
+		counts[t] += indexTitleWeight
+	}
+	for _, t := range terms(b.Desc + " " + a.Byline + " " + a.Text) {
+		counts[t]++
+	}
+	length := 0
+	doc := make([]string, 0, len(counts))
+	for t, c := range counts {
+		if idx.data.Terms[t] == nil {
+			idx.data.Terms[t] = make(map[string]int)
+		}
+		idx.data.Terms[t][key] = c
+		length += c
+		doc = append(doc, t)
+	}
+	sort.Strings(doc)
+	idx.data.Docs[key] = indexDoc{URL: b.URL,
+		Title:  firstNonEmpty(b.Title, a.Title),
+		Length: length,
+		Terms:  doc,
+	}
+}
+
+// Remove removes a bookmark from the index.
+func (idx *Index) Remove(b Bookmark) {
+	key := archiveKey(b)
+	d, ok := idx.data.Docs[key]
+	if !ok {
+		return
+	}
+	for _, t := range d.Terms {
+		docs := idx.data.Terms[t]
+		delete(docs, key)
+		if len(docs) == 0 {
+			delete(idx.data.Terms, t)
+		}
+	}
+	delete(idx.data.Docs, key)
+}
+
+// BM25 parameters.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Search returns the n best bookmarks containing every term of query, best
+// first. If n is 0 all matches are returned.
+func (idx *Index) Search(query string, n int) []SearchResult {
+	q := terms(query)
+	if len(q) == 0 || len(idx.data.Docs) == 0 {
+		return nil
+	}
+	total := 0
+	for _, d := range idx.data.Docs {
+		total += d.Length
+	}
+	avg := float64(total) / float64(len(idx.data.Docs))
+
+	scores := make(map[string]float64)
+	matched := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, t := range q {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		docs := idx.data.Terms[t]
+		df := float64(len(docs))
+		idf := math.Log(1 + (float64(len(idx.data.Docs))-df+0.5)/(df+0.5))
+		for key, tf := range docs {
+			l := float64(idx.data.Docs[key].Length)
+			f := float64(tf)
+			scores[key] += idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*l/avg))
+			matched[key]++
+		}
+	}
+	var results []SearchResult
+	for key, s := range scores {
+		if matched[key] < len(seen) {
+			continue
+		}
+		d := idx.data.Docs[key]
+		results = append(results, SearchResult{URL: d.URL, Title: d.Title, Score: s})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].URL < results[j].URL
+	})
+	if n > 0 && len(results) > n {
+		results = results[:n]
+	}
+	return results
+}
+
+// IndexArticles adds bookmarks to the index with the articles stored for them
+// in the store, bookmarks without a stored article are indexed on their own.
+func (s TextStore) IndexArticles(idx *Index, bmarks []Bookmark) error {
+	for _, b := range bmarks {
+		a, _, err := s.Load(b)
+		if err != nil {
+			return err
+		}
+		idx.Add(b, a)
+	}
+	return nil
+}
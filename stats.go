@@ -0,0 +1,223 @@
+package pinboard
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Period represents the length of time posts are aggregated over.
+type Period int
+
+// Periods posts can be aggregated over. Weeks start on a Monday.
+const (
+	Day Period = iota
+	Week
+	Month
+	Year
+)
+
+// Activity represents statistics about the users posting activity.
+type Activity struct {
+	Total         int     // Number of posts.
+	Days          int     // Number of days from the first post up to today.
+	ActiveDays    int     // Number of days with at least one post.
+	Average       float64 // Average number of posts per day.
+	LongestStreak int     // Longest run of consecutive days with posts.
+	CurrentStreak int     // Run of consecutive days with posts up to today.
+	Busiest       Post    // Day with the most posts.
+}
+
+// truncateDay returns t at midnight UTC.
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// startOf returns the start of the period containing t.
+func startOf(t time.Time, p Period) time.Time {
+	t = truncateDay(t)
+	switch p {
+	case Week:
+		// time.Sunday is 0, weeks start on a Monday.
+		wd := (int(t.Weekday()) + 6) % 7
+		return t.AddDate(0, 0, -wd)
+	case Month:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case Year:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return t
+}
+
+// DailySeries returns posts as a series with one post per day, sorted by date
+// from the first to the last day in posts. Counts on the same day are summed
+// and days without posts have a count of 0.
+func DailySeries(posts []Post) []Post {
+	if len(posts) == 0 {
+		return nil
+	}
+	counts := make(map[time.Time]int)
+	first := truncateDay(posts[0].Date)
+	last := first
+	for _, p := range posts {
+		d := truncateDay(p.Date)
+		counts[d] += p.Count
+		if d.Before(first) {
+			first = d
+		}
+		if d.After(last) {
+			last = d
+		}
+	}
+	var series []Post
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		series = append(series, Post{Date: d, Count: counts[d]})
+	}
+	return series
+}
+
+// Aggregate sums the counts of posts over each period, the date of each post
+// returned is the start of its period. Posts are sorted by date, periods
+// without posts are omitted.
+func Aggregate(posts []Post, p Period) []Post {
+	counts := make(map[time.Time]int)
+	for _, o := range posts {
+		counts[startOf(o.Date, p)] += o.Count
+	}
+	var agg []Post
+	for d, c := range counts {
+		agg = append(agg, Post{Date: d, Count: c})
+	}
+	sort.Slice(agg, func(i, j int) bool { return agg[i].Date.Before(agg[j].Date) })
+	return agg
+}
+
+// Summarize returns statistics about posts up to and including today.
+func Summarize(posts []Post, today time.Time) Activity {
+	var a Activity
+	today = truncateDay(today)
+	var series []Post
+	for _, p := range DailySeries(posts) {
+		if !p.Date.After(today) {
+			series = append(series, p)
+		}
+	}
+	if len(series) == 0 {
+		return a
+	}
+	// extend the series up to today, so the current streak is broken by days
+	// without posts.
+	for d := series[len(series)-1].Date.AddDate(0, 0, 1); !d.After(today); d = d.AddDate(0, 0, 1) {
+		series = append(series, Post{Date: d})
+	}
+	run := 0
+	for _, p := range series {
+		a.Total += p.Count
+		if p.Count == 0 {
+			run = 0
+			continue
+		}
+		a.ActiveDays++
+		run++
+		if run > a.LongestStreak {
+			a.LongestStreak = run
+		}
+		if p.Count > a.Busiest.Count {
+			a.Busiest = p
+		}
+	}
+	a.CurrentStreak = run
+	a.Days = len(series)
+	a.Average = float64(a.Total) / float64(a.Days)
+	return a
+}
+
+// heatmapWeeks is the number of weeks shown by a heatmap.
+const heatmapWeeks = 53
+
+// heatmap returns the first day shown by a heatmap ending on end, the count of
+// posts on each day and the highest count. Like GitHub, columns are weeks
+// starting on a Sunday and the last column holds end.
+func heatmap(posts []Post, end time.Time) (time.Time, map[time.Time]int, int) {
+	end = truncateDay(end)
+	start := end.AddDate(0, 0, -int(end.Weekday())-7*(heatmapWeeks-1))
+	counts := make(map[time.Time]int)
+	max := 0
+	for _, p := range posts {
+		d := truncateDay(p.Date)
+		if d.Before(start) || d.After(end) {
+			continue
+		}
+		counts[d] += p.Count
+		if counts[d] > max {
+			max = counts[d]
+		}
+	}
+	return start, counts, max
+}
+
+// heatLevel maps a count to one of five levels, 0 for no posts and 1 to 4 for
+// quarters of the highest count.
+func heatLevel(count, max int) int {
+	if count <= 0 || max <= 0 {
+		return 0
+	}
+	return (4*count + max - 1) / max
+}
+
+// heatColours are the fill colours of each heat level.
+var heatColours = [5]string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"}
+
+// WriteHeatmapSVG writes a GitHub style calendar heatmap of the year of posts
+// up to end as an SVG image.
+func WriteHeatmapSVG(w io.Writer, posts []Post, end time.Time) error {
+	const cell, gap, top, left = 10, 2, 15, 25
+	start, counts, max := heatmap(posts, end)
+	end = truncateDay(end)
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`+"\n",
+		left+heatmapWeeks*(cell+gap), top+7*(cell+gap))
+	for i, l := range []string{"Mon", "Wed", "Fri"} {
+		fmt.Fprintf(b, `<text x="0" y="%d" font-size="9">%s</text>`+"\n",
+			top+(2*i+1)*(cell+gap)+cell-1, l)
+	}
+	month := time.Month(0)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		week := int(d.Sub(start).Hours()/24) / 7
+		x := left + week*(cell+gap)
+		if d.Weekday() == time.Sunday && d.Month() != month {
+			month = d.Month()
+			fmt.Fprintf(b, `<text x="%d" y="10" font-size="9">%s</text>`+"\n",
+				x, d.Month().String()[:3])
+		}
+		c := counts[d]
+		fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s">`+
+			`<title>%s: %d</title></rect>`+"\n",
+			x, top+int(d.Weekday())*(cell+gap), cell, cell,
+			heatColours[heatLevel(c, max)], d.Format("2006-01-02"), c)
+	}
+	fmt.Fprintln(b, "</svg>")
+	return b.Flush()
+}
+
+// heatBlocks are the characters used for each heat level in a terminal.
+var heatBlocks = [5]string{"·", "░", "▒", "▓", "█"}
+
+// WriteHeatmapText writes a calendar heatmap of the year of posts up to end
+// for display in a terminal, one row per weekday.
+func WriteHeatmapText(w io.Writer, posts []Post, end time.Time) error {
+	start, counts, max := heatmap(posts, end)
+	end = truncateDay(end)
+	b := bufio.NewWriter(w)
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		fmt.Fprintf(b, "%s ", wd.String()[:3])
+		for d := start.AddDate(0, 0, int(wd)); !d.After(end); d = d.AddDate(0, 0, 7) {
+			fmt.Fprint(b, heatBlocks[heatLevel(counts[d], max)])
+		}
+		fmt.Fprintln(b)
+	}
+	return b.Flush()
+}
@@ -0,0 +1,281 @@
+package pinboard
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// EventType is the kind of change an Event reports.
+type EventType int
+
+// Kinds of changes.
+const (
+	Added EventType = iota
+	Updated
+	Deleted
+)
+
+// String returns the name of the event type as used in webhooks.
+func (t EventType) String() string {
+	switch t {
+	case Added:
+		return "added"
+	case Updated:
+		return "updated"
+	case Deleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+// MarshalJSON encodes the event type as its name.
+func (t EventType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// Event reports a bookmark which was added, updated or deleted. For deleted
+// bookmarks Bookmark is the last version seen.
+type Event struct {
+	Type     EventType
+	Bookmark Bookmark
+	Time     time.Time // Time the change was noticed.
+}
+
+// webhookBookmark is a bookmark in a webhook payload.
+type webhookBookmark struct {
+	URL     string   `json:"url"`
+	Title   string   `json:"title"`
+	Desc    string   `json:"description"`
+	Tags    []string `json:"tags"`
+	Created string   `json:"created,omitempty"`
+	Shared  bool     `json:"shared"`
+	ToRead  bool     `json:"toread"`
+}
+
+// MarshalJSON encodes the event as a webhook payload.
+func (e Event) MarshalJSON() ([]byte, error) {
+	b := webhookBookmark{URL: e.Bookmark.URL,
+		Title:  e.Bookmark.Title,
+		Desc:   e.Bookmark.Desc,
+		Tags:   uniqueTags(e.Bookmark.Tags),
+		Shared: e.Bookmark.Shared,
+		ToRead: e.Bookmark.ToRead,
+	}
+	if b.Tags == nil {
+		b.Tags = []string{}
+	}
+	if !e.Bookmark.Created.IsZero() {
+		b.Created = e.Bookmark.Created.UTC().Format(time.RFC3339)
+	}
+	return json.Marshal(struct {
+		Type     EventType       `json:"type"`
+		Time     string          `json:"time"`
+		Bookmark webhookBookmark `json:"bookmark"`
+	}{e.Type, e.Time.UTC().Format(time.RFC3339), b})
+}
+
+// SignatureHeader is the header webhooks carry their signature in, the hex
+// encoded HMAC-SHA256 of the body prefixed with "sha256=".
+const SignatureHeader = "X-Pinboard-Signature"
+
+// sign returns the signature of body.
+func sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature, the value of the SignatureHeader
+// of a webhook, is valid for body and secret.
+func VerifySignature(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(sign(secret, body)), []byte(signature))
+}
+
+// Watcher watches the users account for changes. It polls LastUpdate, which
+// is cheap, and only fetches and diffs bookmarks when the time moves.
+type Watcher struct {
+	Pinboard *Pinboard
+	// Time between LastUpdate polls, default is 1 minute.
+	Interval time.Duration
+	// Minimum time between posts/all requests, default is 5 minutes as asked
+	// by Pinboard. Changes are reported late rather than breaking the limit.
+	FullInterval time.Duration
+	// RecentOnly diffs the 100 most recent bookmarks with posts/recent instead
+	// of all bookmarks. It is cheaper but misses edits to older bookmarks and
+	// never reports deletions.
+	RecentOnly bool
+	// Webhook is a URL every event is POSTed to as JSON, signed with Secret
+	// if set.
+	Webhook string
+	Secret  []byte
+	// Client sends webhooks, default is http.DefaultClient.
+	Client *http.Client
+	// OnError is called with errors polling Pinboard or sending webhooks,
+	// the watcher carries on at the next poll.
+	OnError func(error)
+}
+
+// Watch starts watching in the background and returns the channel events are
+// sent on. The first poll takes a snapshot of the bookmarks, later polls
+// report changes against it. Events must be received for the watcher to make
+// progress, the channel is closed once ctx is done.
+func (w *Watcher) Watch(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		w.run(ctx, events)
+	}()
+	return events
+}
+
+// run polls until ctx is done.
+func (w *Watcher) run(ctx context.Context, events chan<- Event) {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	full := w.FullInterval
+	if full <= 0 {
+		full = 5 * time.Minute
+	}
+	var (
+		snapshot map[string]Bookmark
+		seen     time.Time // LastUpdate of the snapshot.
+		fetched  time.Time // time of the last posts/all request.
+	)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		last, err := w.Pinboard.LastUpdate()
+		switch {
+		case err != nil:
+			w.error(err)
+		case snapshot != nil && !last.After(seen):
+			// nothing changed.
+		case !w.RecentOnly && time.Since(fetched) < full:
+			// changed, but posts/all can't be requested yet.
+		default:
+			bmarks, err := w.fetch()
+			if !w.RecentOnly {
+				fetched = time.Now()
+			}
+			if err != nil {
+				w.error(err)
+				break
+			}
+			current := byURL(bmarks)
+			if snapshot != nil {
+				for _, e := range w.diff(snapshot, current) {
+					select {
+					case events <- e:
+					case <-ctx.Done():
+						return
+					}
+					w.send(e)
+				}
+			}
+			if w.RecentOnly && snapshot != nil {
+				for u, b := range current {
+					snapshot[u] = b
+				}
+			} else {
+				snapshot = current
+			}
+			seen = last
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// fetch returns the bookmarks to diff.
+func (w *Watcher) fetch() ([]Bookmark, error) {
+	if w.RecentOnly {
+		return w.Pinboard.Recent(nil, 100)
+	}
+	return w.Pinboard.Bookmarks(nil, 0, 0, time.Time{}, time.Time{}, false)
+}
+
+// diff returns the changes between two snapshots, sorted by URL within each
+// kind of change.
+func (w *Watcher) diff(old, current map[string]Bookmark) []Event {
+	now := time.Now().UTC()
+	var added, updated, deleted []Event
+	for u, b := range current {
+		o, ok := old[u]
+		switch {
+		case !ok:
+			added = append(added, Event{Type: Added, Bookmark: b, Time: now})
+		case fingerprint(o) != fingerprint(b):
+			updated = append(updated, Event{Type: Updated, Bookmark: b, Time: now})
+		}
+	}
+	if !w.RecentOnly {
+		for u, o := range old {
+			if _, ok := current[u]; !ok {
+				deleted = append(deleted, Event{Type: Deleted, Bookmark: o, Time: now})
+			}
+		}
+	}
+	var events []Event
+	for _, l := range [][]Event{added, updated, deleted} {
+		sort.Slice(l, func(i, j int) bool { return l[i].Bookmark.URL < l[j].Bookmark.URL })
+		events = append(events, l...)
+	}
+	return events
+}
+
+// send POSTs e to the webhook.
+func (w *Watcher) send(e Event) {
+	if w.Webhook == "" {
+		return
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		w.error(err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, w.Webhook, bytes.NewReader(body))
+	if err != nil {
+		w.error(err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Pinboard-Event", e.Type.String())
+	if w.Secret != nil {
+		req.Header.Set(SignatureHeader, sign(w.Secret, body))
+	}
+	c := w.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	rsp, err := c.Do(req)
+	if err != nil {
+		w.error(err)
+		return
+	}
+	rsp.Body.Close()
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		w.error(errors.New("webhook: HTTP " + strconv.Itoa(rsp.StatusCode) + " " +
+			http.StatusText(rsp.StatusCode)))
+	}
+}
+
+// error reports err to OnError.
+func (w *Watcher) error(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}